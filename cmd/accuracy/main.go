@@ -1,127 +1,63 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
 )
 
+var flagThreshold = flag.Float64("threshold", 0.0, "decision threshold on the margin output_1 - output_0.")
+
 func main() {
-	label_true, _ := ReadCSVToFloat64Slice_lastcolumn("../../data/test_data_breast-cancer.csv")
-	label_true = Transpose(label_true)
+	flag.Parse()
+
+	data, err := dataset.Load("../../data/test_data_breast-cancer.csv", dataset.BreastCancer)
+	if err != nil {
+		panic(err)
+	}
+	label_true := data.Labels
 	// fmt.Println(label_true)
 
-	result_plain, _ := ReadCSVToFloat64Slice("../../result/KAN_plaintext.csv")
-	result_plain = Transpose(result_plain)
+	result_plain, err := dataset.ReadMatrix("../../result/KAN_plaintext.csv")
+	if err != nil {
+		panic(err)
+	}
+	result_plain = dataset.Transpose(result_plain)
 
-	result_cipher, _ := ReadCSVToFloat64Slice("../../result/KAN_ciphertext.csv")
-	result_cipher = Transpose(result_cipher)
+	result_cipher, err := dataset.ReadMatrix("../../result/KAN_ciphertext.csv")
+	if err != nil {
+		panic(err)
+	}
+	result_cipher = dataset.Transpose(result_cipher)
 
 	
-	label_plain := Compare(result_plain[0], result_plain[1])
-	label_cipher := Compare(result_cipher[0], result_cipher[1])
+	// The class is 1 when the second output is the larger one.
+	score_plain := metrics.Margin(result_plain[0], result_plain[1])
+	score_cipher := metrics.Margin(result_cipher[0], result_cipher[1])
+	label_plain := metrics.Threshold(score_plain, *flagThreshold)
+	label_cipher := metrics.Threshold(score_cipher, *flagThreshold)
 
-	accuracy_plain := CountAccuracy(label_plain, label_true[0])
-	accuracy_cipher := CountAccuracy(label_cipher, label_true[0])
-    accuracy_cipher_check := CheckAccuracy_3(label_true[0], label_plain, label_cipher)
+	accuracy_plain := CountAccuracy(label_plain, label_true)
+	accuracy_cipher := CountAccuracy(label_cipher, label_true)
+    accuracy_cipher_check := CheckAccuracy_3(label_true, label_plain, label_cipher)
 
 	fmt.Println(accuracy_plain, accuracy_cipher, accuracy_cipher_check)
 
-}
-
-func ReadCSVToFloat64Slice_lastcolumn(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容，跳过第一行，并只关注最后一列
-    for i, record := range records {
-        if i == 0 {
-            // 跳过第一行（通常是标题行）
-            continue
-        }
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := len(record)-1; j < len(record); j++ { // 只要最后一列
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
-
-func ReadCSVToFloat64Slice(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容
-    for _, record := range records {
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := 0; j < len(record); j++ { 
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
-
-func Compare(input_0 []float64, input_1 []float64) (output []float64) {
-	
-	num := len(input_0)
-	output = make([]float64, num)
-	for i:=0;i<num;i++ {
-		if input_0[i] < input_1[i] {
-			output[i] = 1.0
-		}
-	}
-	return output
+	fmt.Println()
+	metrics.WriteReports(os.Stdout,
+		metrics.NewReport("plaintext", score_plain, label_true, *flagThreshold, false),
+		metrics.NewReport("ciphertext", score_cipher, label_true, *flagThreshold, false),
+	)
+
+	fmt.Println()
+	thresholds := metrics.Linspace(-100, 20, 13)
+	metrics.WriteSweep(os.Stdout, []string{"plaintext", "ciphertext"},
+		metrics.Sweep(score_plain, label_true, thresholds),
+		metrics.Sweep(score_cipher, label_true, thresholds),
+	)
 }
 
 func CheckAccuracy_3(input_0, input_1, input_2 []float64) (accuracy float64) {
@@ -150,25 +86,3 @@ func CountAccuracy(input_0, input_1 []float64) (accuracy float64) {
 	accuracy = match / float64(num)
 	return accuracy
 }
-
-func Transpose(data [][]float64) [][]float64 {
-	if len(data) == 0 {
-		return nil
-	}
-
-	rows := len(data)
-	cols := len(data[0])
-
-	transposed := make([][]float64, cols)
-	for i := range transposed {
-		transposed[i] = make([]float64, rows)
-	}
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			transposed[j][i] = data[i][j]
-		}
-	}
-
-	return transposed
-}
\ No newline at end of file
@@ -1,135 +1,58 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
 )
 
+var flagThreshold = flag.Float64("threshold", 0.5, "decision threshold on the predicted probability of sepsis.")
+
 func main() {
-	label_true, _ := ReadCSVToFloat64Slice_lastcolumn("../../data/test_data_sepsis.csv")
-	label_true = Transpose(label_true)
+	flag.Parse()
+
+	data, err := dataset.Load("../../data/test_data_sepsis.csv", dataset.Sepsis)
+	if err != nil {
+		panic(err)
+	}
+	label_true := data.Labels
 	// fmt.Println(label_true)
 
-	result_plain, _ := ReadCSVToFloat64Slice("../../result/sepsis_KAN_plain.csv")
-	result_plain = Transpose(result_plain)
-    result_cipher, _ := ReadCSVToFloat64Slice("../../result/sepsis_KAN_cipher.csv")
-	result_cipher = Transpose(result_cipher)
-
-    label_plain := make([]float64, len(result_plain[0]))
-    for i:=0;i<len(result_plain[0]);i++ {
-        if result_plain[0][i] > 0.5 {
-            label_plain[i] = 1.0
-        }
-    }
-    label_cipher := make([]float64, len(result_cipher[0]))
-    for i:=0;i<len(result_cipher[0]);i++ {
-        if result_cipher[0][i] > 0.5 {
-            label_cipher[i] = 1.0
-        }
-    }
-
-	accuracy_plain := CountAccuracy(label_plain, label_true[0])
-    accuracy_cipher := CountAccuracy(label_cipher, label_true[0])
-    accuracy_cipher_check := CheckAccuracy_3(label_true[0], label_plain, label_cipher)
+	result_plain, err := dataset.ReadMatrix("../../result/sepsis_KAN_plain.csv")
+	if err != nil {
+		panic(err)
+	}
+	result_plain = dataset.Transpose(result_plain)
+	result_cipher, err := dataset.ReadMatrix("../../result/sepsis_KAN_cipher.csv")
+	if err != nil {
+		panic(err)
+	}
+	result_cipher = dataset.Transpose(result_cipher)
 
-	fmt.Println(accuracy_plain, accuracy_cipher, accuracy_cipher_check)
+	label_plain := metrics.Threshold(result_plain[0], *flagThreshold)
+	label_cipher := metrics.Threshold(result_cipher[0], *flagThreshold)
 
-}
+	accuracy_plain := CountAccuracy(label_plain, label_true)
+    accuracy_cipher := CountAccuracy(label_cipher, label_true)
+    accuracy_cipher_check := CheckAccuracy_3(label_true, label_plain, label_cipher)
 
-func ReadCSVToFloat64Slice_lastcolumn(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容，跳过第一行，并只关注最后一列
-    for i, record := range records {
-        if i == 0 {
-            // 跳过第一行（通常是标题行）
-            continue
-        }
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := len(record)-1; j < len(record); j++ { // 只要最后一列
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
+	fmt.Println(accuracy_plain, accuracy_cipher, accuracy_cipher_check)
 
-func ReadCSVToFloat64Slice(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容
-    for _, record := range records {
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := 0; j < len(record); j++ { 
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
-
-func Compare(input_0 []float64, input_1 []float64) (output []float64) {
-	
-	num := len(input_0)
-	output = make([]float64, num)
-	for i:=0;i<num;i++ {
-		if input_0[i] < input_1[i] {
-			output[i] = 1.0
-		}
-	}
-	return output
+	fmt.Println()
+	metrics.WriteReports(os.Stdout,
+		metrics.NewReport("plaintext", result_plain[0], label_true, *flagThreshold, true),
+		metrics.NewReport("ciphertext", result_cipher[0], label_true, *flagThreshold, true),
+	)
+
+	fmt.Println()
+	thresholds := metrics.Linspace(0.1, 0.9, 9)
+	metrics.WriteSweep(os.Stdout, []string{"plaintext", "ciphertext"},
+		metrics.Sweep(result_plain[0], label_true, thresholds),
+		metrics.Sweep(result_cipher[0], label_true, thresholds),
+	)
 }
 
 func CheckAccuracy_3(input_0, input_1, input_2 []float64) (accuracy float64) {
@@ -158,25 +81,3 @@ func CountAccuracy(input_0, input_1 []float64) (accuracy float64) {
 	accuracy = match / float64(num)
 	return accuracy
 }
-
-func Transpose(data [][]float64) [][]float64 {
-	if len(data) == 0 {
-		return nil
-	}
-
-	rows := len(data)
-	cols := len(data[0])
-
-	transposed := make([][]float64, cols)
-	for i := range transposed {
-		transposed[i] = make([]float64, rows)
-	}
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			transposed[j][i] = data[i][j]
-		}
-	}
-
-	return transposed
-}
\ No newline at end of file
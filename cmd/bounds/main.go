@@ -0,0 +1,99 @@
+// Package main propagates guaranteed bounds through a model and compares them
+// with the declared Chebyshev intervals and with the values observed on a
+// dataset.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/src"
+)
+
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of the samples, for the empirical bounds.")
+var flagFactor = flag.Float64("factor", 4, "warn when a guaranteed bound is this many times wider than the empirical one.")
+var flagMargin = flag.Float64("margin", 0.05, "relative margin added to the intervals written by -out.")
+var flagOut = flag.String("out", "", "write the model with the guaranteed intervals to this file.")
+var flagNormalize = flag.Bool("normalize", false, "with -out, write instead the model normalized to [-1, 1] on the empirical bounds.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+	if err != nil {
+		panic(err)
+	}
+	empirical := m.EmpiricalBounds(data.Rows)
+
+	ranges := m.Ranges
+	if ranges == nil {
+		// Without declared ranges, the observed ones are the best we have,
+		// and the guaranteed bounds only hold for inputs within them.
+		fmt.Println("warning: the model declares no feature ranges, using the ranges of the dataset")
+		ranges = make([][]float64, len(m.Features))
+		columns := data.Columns()
+		for j := range columns {
+			ranges[j] = []float64{math.Inf(1), math.Inf(-1)}
+			for _, v := range columns[j] {
+				ranges[j][0] = math.Min(ranges[j][0], v)
+				ranges[j][1] = math.Max(ranges[j][1], v)
+			}
+		}
+	}
+
+	guaranteed, errs := m.PropagateBounds(ranges)
+	for _, err := range errs {
+		fmt.Println("warning:", err)
+	}
+
+	dead := m.Dead()
+	fmt.Printf("%-6s %-5s %-9s %-24s %-24s %-24s %s\n", "layer", "node", "act", "interval", "guaranteed", "empirical", "")
+	for l, layer := range m.Layers {
+		for i, ns := range layer.Nodes {
+			g, e := guaranteed.Pre[l][i], empirical.Pre[l][i]
+
+			// The Chebyshev interpolant of identity is exact on any interval.
+			exact := ns.Activation == "identity"
+			var notes []string
+			switch {
+			case dead[l][i]:
+				notes = append(notes, "dead")
+			case !exact && (g[0] < ns.Interval[0] || g[1] > ns.Interval[1]):
+				notes = append(notes, "may leave its interval")
+			}
+			if !exact && (e[0] < ns.Interval[0] || e[1] > ns.Interval[1]) {
+				notes = append(notes, "leaves its interval on the data")
+			}
+			if width := e[1] - e[0]; !dead[l][i] && g[1]-g[0] > *flagFactor*math.Max(width, 1e-9) {
+				notes = append(notes, fmt.Sprintf("guaranteed %.1fx wider than empirical", (g[1]-g[0])/math.Max(width, 1e-9)))
+			}
+
+			fmt.Printf("%-6d %-5d %-9s %-24s %-24s %-24s %s\n", l, i, ns.Activation, pair(ns.Interval), pair(g), pair(e), strings.Join(notes, "; "))
+		}
+	}
+
+	if *flagOut != "" {
+		var out src.Model
+		if *flagNormalize {
+			out = m.Normalize(empirical, *flagMargin)
+		} else {
+			out = m.WithIntervals(guaranteed.Pre, *flagMargin)
+		}
+		if err = src.WriteModel(*flagOut, out); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func pair(interval []float64) string {
+	return fmt.Sprintf("[%.4g, %.4g]", interval[0], interval[1])
+}
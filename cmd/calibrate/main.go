@@ -0,0 +1,66 @@
+// Package main records the distribution of the input of every activation of a
+// model on calibration samples, and switches the nodes to an approximation
+// that uses it.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/src"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/ring"
+)
+
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of the calibration samples.")
+var flagApproximation = flag.String("approximation", "leastsquares", "approximation of the calibrated nodes.")
+var flagSamples = flag.Int("samples", 256, "number of quantiles kept per node.")
+var flagOut = flag.String("out", "", "write the calibrated model to this file.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+	if err != nil {
+		panic(err)
+	}
+	c := m.Calibrate(data.Rows, *flagSamples, *flagApproximation)
+	if err = c.Check(); err != nil {
+		panic(err)
+	}
+
+	// Compares the approximation errors on the samples and over the intervals.
+	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+		LogN:            13,
+		LogQ:            []int{55, 40},
+		LogP:            []int{61},
+		LogDefaultScale: 40,
+		Xs:              ring.Ternary{H: 192},
+	})
+	if err != nil {
+		panic(err)
+	}
+	before, after := m.ErrorBudget(src.NewNoise(params, 0), nil), c.ErrorBudget(src.NewNoise(params, 0), nil)
+	fmt.Printf("%-6s %-5s %-9s %-16s %14s %14s %14s %14s\n", "layer", "node", "act", "interval", "rms before", "rms after", "max before", "max after")
+	for l, layer := range c.Layers {
+		for i, ns := range layer.Nodes {
+			interval := fmt.Sprintf("[%.4g, %.4g]", ns.Interval[0], ns.Interval[1])
+			fmt.Printf("%-6d %-5d %-9s %-16s %14.4e %14.4e %14.4e %14.4e\n", l, i, ns.Activation, interval,
+				m.Layers[l].Nodes[i].SampleError(ns.Samples), ns.SampleError(ns.Samples), before.Approximation[l][i], after.Approximation[l][i])
+		}
+	}
+	fmt.Printf("\noutput error bound: %v before, %v after\n", before.Outputs, after.Outputs)
+
+	if *flagOut != "" {
+		if err = src.WriteModel(*flagOut, c); err != nil {
+			panic(err)
+		}
+	}
+}
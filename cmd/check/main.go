@@ -1,16 +1,20 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
 	"math"
 	"os"
 	"sort"
-	"strconv"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
 )
 
 func main() {
-	input, _ := ReadCSVToFloat64Slice("../../data/test_data_breast-cancer.csv")
+	data, err := dataset.Load("../../data/test_data_breast-cancer.csv", dataset.BreastCancer)
+	if err != nil {
+		panic(err)
+	}
+	input := data.Rows
 	for i:=0;i<len(input);i++ {
 		PrintPrecision(KAN(input[i]))
 	}
@@ -102,49 +106,6 @@ func MultiplyAndSum(slice1, slice2 []float64) float64 {
     return sum
 }
 
-func ReadCSVToFloat64Slice(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容，跳过第一行，并忽略每一行的最后一列
-    for i, record := range records {
-        if i == 0 {
-            // 跳过第一行（通常是标题行）
-            continue
-        }
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := 0; j < len(record)-1; j++ { // 忽略最后一列
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
-
 func SortFloat64(slice []float64) []float64 {
 
 	sortedSlice := make([]float64, len(slice))
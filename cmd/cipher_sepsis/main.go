@@ -2,14 +2,12 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"math"
 	"math/big"
-	"os"
-	"strconv"
 
+	"github.com/JohnJimAir/asimpnetwork/dataset"
 	"github.com/JohnJimAir/asimpnetwork/src"
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
 	"github.com/tuneinsight/lattigo/v5/he/hefloat"
@@ -35,8 +33,11 @@ func main() {
 
 	
 	var err error
-	input, _ := ReadCSVToFloat64Slice("../../data/test_data_sepsis.csv")
-	input = Transpose(input)
+	data, err := dataset.Load("../../data/test_data_sepsis.csv", dataset.Sepsis)
+	if err != nil {
+		panic(err)
+	}
+	input := data.Columns()
 	
 
 	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
@@ -236,9 +237,9 @@ func main() {
 	fmt.Println(len(input_ct_2d))
 
 	var blo_top *src.Block = new(src.Block)
-	coefficient_top_mult := [][]float64{{-1.0000001},{0.58},{0.4},{0.14},{0.2},
-		{0.0000001},{0.0000001},{1.06},{0.0000001},{0.28},
-		{1.0000001},{0.0000001},{0.0000001},{3.4},{0.0000001},
+	coefficient_top_mult := [][]float64{{-1},{0.58},{0.4},{0.14},{0.2},
+		{0},{0},{1.06},{0},{0.28},
+		{1},{0},{0},{3.4},{0},
 		{-1.38},{0.27},{9.96},{0.31},{0.89},
 		{0.95},{0.43},{0.41},{0.31},{0.16},
 		{0.18},{0.17},{1.02},{0.21},{0.23},
@@ -300,7 +301,7 @@ func main() {
 	// PrintValuesMany(params, out_blo_trick, encoder, decryptor)
 
 	re := PrintValuesMany(params, out_blo_trick, encoder, decryptor)
-	re = Transpose(re)
+	re = dataset.Transpose(re)
 	for i:=0;i<138;i++ {
 		fmt.Printf("%.8f", re[i][0])
 		fmt.Println()
@@ -434,67 +435,3 @@ func EncryptMany(params hefloat.Parameters, encoder *hefloat.Encoder, encryptor
 	return output
 }
 
-func Transpose(data [][]float64) [][]float64 {
-	if len(data) == 0 {
-		return nil
-	}
-
-	rows := len(data)
-	cols := len(data[0])
-
-	transposed := make([][]float64, cols)
-	for i := range transposed {
-		transposed[i] = make([]float64, rows)
-	}
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			transposed[j][i] = data[i][j]
-		}
-	}
-
-	return transposed
-}
-
-func ReadCSVToFloat64Slice(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容，跳过第一行，并忽略每一行的最后一列
-    for i, record := range records {
-        if i == 0 {
-            // 跳过第一行（通常是标题行）
-            continue
-        }
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := 0; j < len(record)-1; j++ { // 忽略最后一列
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
\ No newline at end of file
@@ -2,14 +2,12 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"math"
 	"math/big"
-	"os"
-	"strconv"
 
+	"github.com/JohnJimAir/asimpnetwork/dataset"
 	"github.com/JohnJimAir/asimpnetwork/src"
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
 	"github.com/tuneinsight/lattigo/v5/he/hefloat"
@@ -35,8 +33,11 @@ func main() {
 
 	
 	var err error
-	input, _ := ReadCSVToFloat64Slice("../../data/test_data_breast-cancer.csv")
-	input = Transpose(input)
+	data, err := dataset.Load("../../data/test_data_breast-cancer.csv", dataset.BreastCancer)
+	if err != nil {
+		panic(err)
+	}
+	input := data.Columns()
 	
 
 	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
@@ -172,7 +173,7 @@ func main() {
 		Input: []*rlwe.Ciphertext{ct_0_in},
 	}
 
-	out := nn.Forward(16, 31, eval, params)
+	out := nn.Forward([]float64{-16.0, 16.0}, 31, eval, params)
 	fmt.Println("nnnnnnnnnn")
 	PrintValues(params, out, encoder, decryptor)
 
@@ -225,48 +226,48 @@ func main() {
 	input_ct_2d := [][]*rlwe.Ciphertext{{input_ct[0]}, {input_ct[1]}, {input_ct[2]}, {input_ct[3]}, {input_ct[4]}, {input_ct[5]}, {input_ct[6]}, {input_ct[7]}, {input_ct[8]}}
 
 	var blo_top_0 *src.Block = new(src.Block)
-	blo_top_0.Initialize(9, [][]float64{{3.77}, {7.07}, {9.52}, {9.96}, {3.64}, {2.24}, {10.000001}, {7.85}, {7.94}}, 
+	blo_top_0.Initialize(9, [][]float64{{3.77}, {7.07}, {9.52}, {9.96}, {3.64}, {2.24}, {10}, {7.85}, {7.94}}, 
 		[]float64{-1.01, -6.21, -8.15, -3.26, -0.62, 8.2, -8.2, 7.58, -0.2}, 
 		[]func (float64) (float64){tanh, sin, sin, abs, sin, sin, tanh, sin, abs},
 		input_ct_2d,
 	)
-	out_blo_top_0 := blo_top_0.Forward([]float64{K,K,K, 8,K,K, K,K,8}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
+	out_blo_top_0 := blo_top_0.Forward([][]float64{{-K,K},{-K,K},{-K,K}, {-8,8},{-K,K},{-K,K}, {-K,K},{-K,K},{-8,8}}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
 	out_blo_top_0_BTS := BTSmany(eval_boot, out_blo_top_0)
 	// fmt.Println("0000000")
 	// PrintValuesMany(params, out_blo_top_0_BTS, encoder, decryptor)
 
 
 	var blo_top_1 *src.Block = new(src.Block)
-	blo_top_1.Initialize(9, [][]float64{{0.000001}, {7.4}, {-1.000001}, {9.6}, {6.44}, {6.11}, {5.2}, {4.95}, {5.89}}, 
-		[]float64{0.000001, 1.19, 0.33, -2.47, -2.23, -0.73, 1.18, 9.62, -2.45}, 
+	blo_top_1.Initialize(9, [][]float64{{0}, {7.4}, {-1}, {9.6}, {6.44}, {6.11}, {5.2}, {4.95}, {5.89}}, 
+		[]float64{0, 1.19, 0.33, -2.47, -2.23, -0.73, 1.18, 9.62, -2.45}, 
 		[]func (float64) (float64){contract, sin, pow2, tanh, sin, sin, sin, sin, tanh},
 		input_ct_2d,
 	)
-	out_blo_top_1 := blo_top_1.Forward([]float64{K,K,K, K,K,K, K,K,K}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
+	out_blo_top_1 := blo_top_1.Forward([][]float64{{-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
 	out_blo_top_1_BTS := BTSmany(eval_boot, out_blo_top_1)
 	// fmt.Println("1111111")
 	// PrintValuesMany(params, out_blo_top_1_BTS, encoder, decryptor)
 
 
 	var blo_top_2 *src.Block = new(src.Block)
-	blo_top_2.Initialize(9, [][]float64{{-1.000001}, {5.08}, {6.62}, {7.21}, {2.2}, {3.24}, {-1.000001}, {-1.000001}, {0.28}}, 
+	blo_top_2.Initialize(9, [][]float64{{-1}, {5.08}, {6.62}, {7.21}, {2.2}, {3.24}, {-1}, {-1}, {0.28}}, 
 		[]float64{0.43, -2.22, 2.99, -5.79, -9.64, -2.6, 0.24, 0.37, 1.0}, 
 		[]func (float64) (float64){pow3, sin, sin, sin, contract, tanh, pow2, pow3, contract},
 		input_ct_2d,
 	)
-	out_blo_top_2 := blo_top_2.Forward([]float64{K,K,K, K,K,K, K,K,K}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
+	out_blo_top_2 := blo_top_2.Forward([][]float64{{-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
 	out_blo_top_2_BTS := BTSmany(eval_boot, out_blo_top_2)
 	// fmt.Println("2222222")
 	// PrintValuesMany(params, out_blo_top_2_BTS, encoder, decryptor)
 
 
 	var blo_top_3 *src.Block = new(src.Block)
-	blo_top_3.Initialize(9, [][]float64{{1.13}, {3.94}, {3.89}, {3.86}, {1.49}, {10.000001}, {3.65}, {9.79}, {7.8}}, 
+	blo_top_3.Initialize(9, [][]float64{{1.13}, {3.94}, {3.89}, {3.86}, {1.49}, {10}, {3.65}, {9.79}, {7.8}}, 
 		[]float64{-9.75, -0.58, -7.86, -8.02, 2.53, -2.6, -1.43, 4.21, -0.84}, 
 		[]func (float64) (float64){contract, tanh, sin, sin, contract, tanh, sin, sin, tanh},
 		input_ct_2d,
 	)
-	out_blo_top_3 := blo_top_3.Forward([]float64{K,K,K, K,K,K, K,K,K}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
+	out_blo_top_3 := blo_top_3.Forward([][]float64{{-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}, {-K,K},{-K,K},{-K,K}}, []int{31,31,31, 31,31,31, 31,31,31}, eval, params)
 	out_blo_top_3_BTS := BTSmany(eval_boot, out_blo_top_3)
 	// fmt.Println("333333")
 	// PrintValuesMany(params, out_blo_top_3_BTS, encoder, decryptor)
@@ -274,17 +275,17 @@ func main() {
 
 	var blo_middle *src.Block = new(src.Block)
 	coefficient_middle := [][]float64{
-		{0.08, 0.39, 0.09, 0.000001/*-0.e-2*/, 0.21, -0.13, 0.19, 0.01, 0.01},
-		{0.000001, 1.38, 2.28, 0.27, 1.64, 0.72, -0.37, -0.87, 0.29},
-		{-0.61, -0.01, -0.04, 0.05, 0.000001/*tan -0.e-2*/, 0.07, 0.05, -0.3, 0.000001/*tan*/},
-		{0.000001/*tan*/, 25.59, 21.1, 19.17, 0.000001/*tan*/, 12.94, 4.29, 12.29, 9.55},
+		{0.08, 0.39, 0.09, 0/*-0.e-2*/, 0.21, -0.13, 0.19, 0.01, 0.01},
+		{0, 1.38, 2.28, 0.27, 1.64, 0.72, -0.37, -0.87, 0.29},
+		{-0.61, -0.01, -0.04, 0.05, 0/*tan -0.e-2*/, 0.07, 0.05, -0.3, 0/*tan*/},
+		{0/*tan*/, 25.59, 21.1, 19.17, 0/*tan*/, 12.94, 4.29, 12.29, 9.55},
 	}
 	blo_middle.Initialize(4, coefficient_middle, 
 		[]float64{-1.0, 5.55, 4.04, 85.59}, 
 		[]func (float64) (float64){pow2, sin, sin, identity},
 		[][]*rlwe.Ciphertext{out_blo_top_0_BTS, out_blo_top_1_BTS, out_blo_top_2_BTS, out_blo_top_3_BTS},
 	)
-	out_blo_middle := blo_middle.Forward([]float64{K,K,K, K}, []int{31,31,31, 31}, eval, params)
+	out_blo_middle := blo_middle.Forward([][]float64{{-K,K},{-K,K},{-K,K}, {-K,K}}, []int{31,31,31, 31}, eval, params)
 	out_blo_middle_BTS := BTSmany(eval_boot, out_blo_middle)
 	// fmt.Println("middle")
 	// PrintValuesMany(params, out_blo_middle_BTS, encoder, decryptor)
@@ -300,7 +301,7 @@ func main() {
 		[]func (float64) (float64){exp, tanh},
 		[][]*rlwe.Ciphertext{out_blo_middle_BTS, out_blo_middle_BTS},
 	)
-	out_blo_bottom := blo_bottom.Forward([]float64{8,8}, []int{31,31}, eval, params)
+	out_blo_bottom := blo_bottom.Forward([][]float64{{-8,8},{-8,8}}, []int{31,31}, eval, params)
 	// fmt.Println("bottom")
 	// PrintValuesMany(params, out_blo_bottom, encoder, decryptor)
 
@@ -310,12 +311,12 @@ func main() {
 		[]func (float64) (float64){identity, identity},
 		[][]*rlwe.Ciphertext{ {out_blo_bottom[0]}, {out_blo_bottom[1]} },
 	)
-	out_blo_trick := blo_trick.Forward([]float64{8,8}, []int{1,1}, eval, params)
+	out_blo_trick := blo_trick.Forward([][]float64{{-8,8},{-8,8}}, []int{1,1}, eval, params)
 	// fmt.Println("trick")
 	// PrintValuesMany(params, out_blo_trick, encoder, decryptor)
 
 	re := PrintValuesMany(params, out_blo_trick, encoder, decryptor)
-	re = Transpose(re)
+	re = dataset.Transpose(re)
 	for i:=0;i<140;i++ {
 		fmt.Printf("%.8f,%.8f", re[i][0], re[i][1])
 		fmt.Println()
@@ -449,67 +450,3 @@ func EncryptMany(params hefloat.Parameters, encoder *hefloat.Encoder, encryptor
 	return output
 }
 
-func Transpose(data [][]float64) [][]float64 {
-	if len(data) == 0 {
-		return nil
-	}
-
-	rows := len(data)
-	cols := len(data[0])
-
-	transposed := make([][]float64, cols)
-	for i := range transposed {
-		transposed[i] = make([]float64, rows)
-	}
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			transposed[j][i] = data[i][j]
-		}
-	}
-
-	return transposed
-}
-
-func ReadCSVToFloat64Slice(filename string) ([][]float64, error) {
-    // 打开 CSV 文件
-    file, err := os.Open(filename)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    // 创建一个 CSV 读取器
-    reader := csv.NewReader(file)
-
-    // 读取所有行
-    records, err := reader.ReadAll()
-    if err != nil {
-        return nil, err
-    }
-
-    // 创建一个二维切片来存储 float64 数据
-    var data [][]float64
-
-    // 遍历 CSV 内容，跳过第一行，并忽略每一行的最后一列
-    for i, record := range records {
-        if i == 0 {
-            // 跳过第一行（通常是标题行）
-            continue
-        }
-
-        // 创建一个切片来存储当前行的 float64 数据
-        var row []float64
-        for j := 0; j < len(record)-1; j++ { // 忽略最后一列
-            // 将字符串转换为 float64
-            value, err := strconv.ParseFloat(record[j], 64)
-            if err != nil {
-                return nil, err
-            }
-            row = append(row, value)
-        }
-        data = append(data, row)
-    }
-
-    return data, nil
-}
\ No newline at end of file
@@ -0,0 +1,130 @@
+// Package main reports how far the decrypted outputs of a model are from its
+// plaintext outputs, and for classifiers why the predicted label of a sample
+// flipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
+	"github.com/JohnJimAir/asimpnetwork/src"
+)
+
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of the samples.")
+var flagPlain = flag.String("plain", "../../result/KAN_plaintext.csv", "plaintext outputs.")
+var flagCipher = flag.String("cipher", "../../result/KAN_ciphertext.csv", "decrypted outputs.")
+var flagThreshold = flag.Float64("threshold", math.NaN(), "decision threshold; NaN selects 0 on the margin of two-output models and 0.5 otherwise.")
+var flagNoise = flag.Float64("noise", 1e-4, "score errors below this are attributed to CKKS noise.")
+var flagSamples = flag.Bool("samples", false, "print the errors of every sample.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+	if err != nil {
+		panic(err)
+	}
+	result_plain, err := dataset.ReadMatrix(*flagPlain)
+	if err != nil {
+		panic(err)
+	}
+	result_cipher, err := dataset.ReadMatrix(*flagCipher)
+	if err != nil {
+		panic(err)
+	}
+	if len(result_plain) != len(result_cipher) || len(result_plain) != data.Len() {
+		panic(fmt.Errorf("%d samples, %d plaintext and %d decrypted outputs", data.Len(), len(result_plain), len(result_cipher)))
+	}
+	result_plain = dataset.Transpose(result_plain)
+	result_cipher = dataset.Transpose(result_cipher)
+
+	// Errors of each output.
+	divergence := make([]metrics.Divergence, len(result_plain))
+	fmt.Printf("%-8s %12s %12s %12s %12s %12s %12s %10s %10s\n", "output", "max", "mean", "p50", "p90", "p99", "max rel", "min bits", "mean bits")
+	for j := range result_plain {
+		divergence[j] = metrics.NewDivergence(result_plain[j], result_cipher[j])
+		abs := metrics.Summarize(divergence[j].Absolute)
+		rel := metrics.Summarize(divergence[j].Relative)
+		fmt.Printf("%-8d %12.4e %12.4e %12.4e %12.4e %12.4e %12.4e %10.2f %10.2f\n", j, abs.Max, abs.Mean, abs.P50, abs.P90, abs.P99, rel.Max, abs.MinBits, abs.MeanBits)
+	}
+
+	if *flagSamples {
+		fmt.Println()
+		fmt.Printf("%-8s", "sample")
+		for j := range result_plain {
+			fmt.Printf(" %14s %14s %12s %12s", fmt.Sprintf("plain_%d", j), fmt.Sprintf("cipher_%d", j), fmt.Sprintf("abs_%d", j), fmt.Sprintf("rel_%d", j))
+		}
+		fmt.Println()
+		for i := range result_plain[0] {
+			fmt.Printf("%-8d", i)
+			for j := range result_plain {
+				fmt.Printf(" %14.8f %14.8f %12.4e %12.4e", result_plain[j][i], result_cipher[j][i], divergence[j].Absolute[i], divergence[j].Relative[i])
+			}
+			fmt.Println()
+		}
+	}
+
+	// Decision rule, for classifiers.
+	if m.IsRegression() {
+		return
+	}
+	threshold := *flagThreshold
+	score_plain, score_cipher := result_plain[0], result_cipher[0]
+	if len(result_plain) == 2 {
+		score_plain = metrics.Margin(result_plain[0], result_plain[1])
+		score_cipher = metrics.Margin(result_cipher[0], result_cipher[1])
+		if math.IsNaN(threshold) {
+			threshold = 0
+		}
+	} else if math.IsNaN(threshold) {
+		threshold = 0.5
+	}
+
+	flipped := metrics.Flipped(metrics.Threshold(score_plain, threshold), metrics.Threshold(score_cipher, threshold))
+	fmt.Println()
+	fmt.Printf("%d of %d labels flipped at threshold %g\n", len(flipped), len(score_plain), threshold)
+	if len(flipped) == 0 {
+		return
+	}
+
+	features := m.FeatureIntervals()
+	dead := m.Dead()
+	fmt.Printf("%-8s %12s %12s %-22s %s\n", "sample", "plain score", "cipher score", "cause", "out of interval")
+	for _, i := range flipped {
+
+		x := data.Rows[i]
+		var out []string
+		for j, name := range m.Features {
+			if x[j] < features[j][0] || x[j] > features[j][1] {
+				out = append(out, fmt.Sprintf("%s=%.4g not in [%.4g, %.4g]", name, x[j], features[j][0], features[j][1]))
+			}
+		}
+		pre := m.Preactivations(x)
+		for l, layer := range m.Layers {
+			for n, ns := range layer.Nodes {
+				if !dead[l][n] && (pre[l][n] < ns.Interval[0] || pre[l][n] > ns.Interval[1]) {
+					out = append(out, fmt.Sprintf("layer %d node %d=%.4g not in %v", l, n, pre[l][n], ns.Interval))
+				}
+			}
+		}
+
+		cause := "approximation"
+		switch {
+		case len(out) != 0:
+			cause = "out of interval"
+		case math.Abs(score_cipher[i]-score_plain[i]) < *flagNoise:
+			cause = "noise on the boundary"
+		}
+		fmt.Printf("%-8d %12.6f %12.6f %-22s %s\n", i, score_plain[i], score_cipher[i], cause, strings.Join(out, "; "))
+	}
+}
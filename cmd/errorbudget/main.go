@@ -0,0 +1,97 @@
+// Package main estimates how the approximation error of every node and the
+// CKKS noise reach the outputs of a model, and ranks the nodes by their share
+// of the output error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/src"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/ring"
+)
+
+var flagShort = flag.Bool("short", false, "use the smaller and insecure ring degree of the examples.")
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagBootstrap = flag.Float64("bootstrap", 20, "precision in bits of a bootstrapping.")
+var flagOutput = flag.Int("output", -1, "rank the nodes on this output, or on the sum of the outputs if negative.")
+var flagApproximation = flag.String("approximation", "", "approximation of every node (chebyshev, minimax, minimax-relative), as in the model if empty.")
+var flagData = flag.String("data", "", "CSV file of samples to take the input bounds of the activations from, the declared feature ranges if empty.")
+var flagTop = flag.Int("top", 10, "number of nodes to list.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+
+	if *flagApproximation != "" {
+		for l := range m.Layers {
+			for i := range m.Layers[l].Nodes {
+				m.Layers[l].Nodes[i].Approximation = *flagApproximation
+			}
+		}
+		if err = m.Check(); err != nil {
+			panic(err)
+		}
+	}
+
+	// Parameters of the examples.
+	LogN := 16
+	if *flagShort {
+		LogN -= 3
+	}
+	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+		LogN:            LogN,
+		LogQ:            []int{55, 40, 40, 40, 40, 40, 40, 40, 40, 40, 40},
+		LogP:            []int{61, 61, 61},
+		LogDefaultScale: 40,
+		Xs:              ring.Ternary{H: 192},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	noise := src.NewNoise(params, math.Exp2(-*flagBootstrap))
+	fmt.Printf("noise model: fresh %.3e, per operation %.3e, bootstrapping %.3e\n\n", noise.Fresh, noise.Op, noise.Bootstrap)
+
+	// Bounds of the inputs of the activations, on which the polynomials are
+	// evaluated.
+	bounds, errs := m.DeclaredBounds()
+	if *flagData != "" {
+		data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+		if err != nil {
+			panic(err)
+		}
+		bounds, errs = m.EmpiricalBounds(data.Rows), nil
+	}
+	for _, err := range errs {
+		fmt.Println("warning:", err)
+	}
+
+	b := m.ErrorBudget(noise, bounds.Pre)
+
+	fmt.Printf("%-8s %12s %12s\n", "output", "error bound", "encryption")
+	for j := range b.Outputs {
+		fmt.Printf("%-8d %12.4e %12.4e\n", j, b.Outputs[j], b.Inputs[j])
+	}
+	fmt.Println()
+
+	fmt.Printf("%-6s %-5s %-9s %-16s %6s %12s %12s %12s %12s %12s\n", "layer", "node", "act", "interval", "degree", "approx", "lipschitz", "approx out", "noise out", "total out")
+	ranking := b.Ranking(*flagOutput)
+	for k, c := range ranking {
+		if k == *flagTop || c.Total() == 0 {
+			break
+		}
+		ns := m.Layers[c.Layer].Nodes[c.Node]
+		interval := fmt.Sprintf("[%.4g, %.4g]", ns.Interval[0], ns.Interval[1])
+		fmt.Printf("%-6d %-5d %-9s %-16s %6d %12.4e %12.4e %12.4e %12.4e %12.4e\n", c.Layer, c.Node, ns.Activation, interval, ns.Degree,
+			b.Approximation[c.Layer][c.Node], b.Lipschitz[c.Layer][c.Node], c.Approximation, c.Noise, c.Total())
+	}
+}
@@ -0,0 +1,329 @@
+// Package main runs a model on encrypted samples: the client preprocesses and
+// encrypts one ciphertext per feature, the server evaluates the model layer by
+// layer and the client decrypts the outputs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
+	"github.com/JohnJimAir/asimpnetwork/multiparty"
+	"github.com/JohnJimAir/asimpnetwork/src"
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils"
+)
+
+var flagShort = flag.Bool("short", false, "run the example with a smaller and insecure ring degree.")
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of the samples.")
+var flagRaw = flag.Bool("raw", false, "the samples are raw exports: apply the model preprocessing before encryption.")
+var flagFold = flag.Bool("fold", false, "fold the affine preprocessing steps into the first layer (requires -raw).")
+var flagClamp = flag.Bool("clamp", false, "clamp the input of every activation into its interval first, but of the polynomial ones, exact beyond it.")
+var flagDomain = flag.Bool("flag", false, "also compute an encrypted out-of-domain flag per sample, printed as a last column.")
+var flagDecide = flag.Bool("decide", false, "return only the encrypted label per sample instead of the outputs.")
+var flagArgmax = flag.Bool("argmax", false, "return only the encrypted one-hot vector of the largest output per sample instead of the outputs.")
+var flagThreshold = flag.Float64("threshold", math.NaN(), "decision threshold on the output, or on the margin output_1 - output_0 for two outputs (with -decide); NaN selects 0 on the margin and 0.5 on a single output.")
+var flagFlood = flag.Int("flood", 0, "statistical security in bits of the flooding noise added to the returned ciphertexts, none if 0.")
+var flagBootstrap = flag.Float64("bootstrap", 20, "precision in bits of a bootstrapping, for the noise estimate of -flood.")
+var flagRound = flag.Int("round", -1, "decimal digits to round the decrypted values to before publishing them, none if negative.")
+var flagParties = flag.Int("parties", 0, "number of data owners generating the keys jointly, a single key holder if 0.")
+var flagQuorum = flag.Int("quorum", 0, "number of parties a decryption takes (with -parties), all of them if 0.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	if *flagDecide && *flagArgmax {
+		panic("-decide and -argmax both replace the outputs, use one of them")
+	}
+	if *flagQuorum != 0 && *flagParties == 0 {
+		panic("-quorum applies to the parties of -parties")
+	}
+	if m.IsRegression() && (*flagDecide || *flagArgmax) {
+		panic("-decide and -argmax label the outputs of a classifier, the outputs of a regression model are its predictions")
+	}
+	if *flagFold {
+		if !*flagRaw {
+			panic("-fold applies to raw samples, use it with -raw")
+		}
+		m = m.FoldPreprocessing()
+	}
+	if *flagClamp {
+		for l := range m.Layers {
+			for i := range m.Layers[l].Nodes {
+				m.Layers[l].Nodes[i].Clamp = !m.Layers[l].Nodes[i].Exact()
+			}
+		}
+	}
+
+	// Client: loads and preprocesses the samples.
+	var rows [][]float64
+	if *flagRaw {
+		schema := m.Schema()
+		schema.Label = ""
+		data, err := dataset.Load(*flagData, schema)
+		if err != nil {
+			panic(err)
+		}
+		if rows, err = m.Prepare(data); err != nil {
+			panic(err)
+		}
+	} else {
+		data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+		if err != nil {
+			panic(err)
+		}
+		rows = data.Rows
+	}
+
+	// Default LogN, which with the following defined parameters
+	// provides a security of 128-bit.
+	LogN := 16
+
+	if *flagShort {
+		LogN -= 3
+	}
+
+	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+		LogN:            LogN,                                              // Log2 of the ring degree
+		LogQ:            []int{55, 40, 40, 40, 40, 40, 40, 40, 40, 40, 40}, // Log2 of the ciphertext prime moduli
+		LogP:            []int{61, 61, 61},                                 // Log2 of the key-switch auxiliary prime moduli
+		LogDefaultScale: 40,                                                // Log2 of the scale
+		Xs:              ring.Ternary{H: 192},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if err = m.Fits(params.MaxLevel()); err != nil {
+		panic(err)
+	}
+
+	btpParametersLit := bootstrapping.ParametersLiteral{
+		LogN: utils.Pointy(LogN),
+		LogP: []int{61, 61, 61, 61},
+		Xs:   params.Xs(),
+	}
+
+	if *flagParties > 0 {
+		btpParametersLit = multiparty.BootstrappingParametersLiteral(btpParametersLit, *flagParties)
+	}
+
+	btpParams, err := bootstrapping.NewParametersFromLiteral(params, btpParametersLit)
+	if err != nil {
+		panic(err)
+	}
+
+	if *flagShort {
+		btpParams.Mod1ParametersLiteral.LogMessageRatio += 16 - params.LogN()
+	}
+
+	// Keys: of a single key holder, or of the parties jointly.
+	var session *multiparty.Session
+	var pk *rlwe.PublicKey
+	var rlk *rlwe.RelinearizationKey
+	var evk_boot *bootstrapping.EvaluationKeys
+	var decryptor *rlwe.Decryptor
+	var active []int
+	if *flagParties > 0 {
+		if *flagQuorum == 0 {
+			*flagQuorum = *flagParties
+		}
+		fmt.Printf("Generating the collective keys of %d parties, %d of them to decrypt...\n", *flagParties, *flagQuorum)
+		if session, err = multiparty.NewSession(params, btpParams, *flagParties, *flagQuorum); err != nil {
+			panic(err)
+		}
+		pk, rlk, evk_boot = session.Public, session.Relinearization, session.Bootstrapping
+		active = make([]int, *flagQuorum)
+		for k := range active {
+			active[k] = k
+		}
+	} else {
+		kgen := rlwe.NewKeyGenerator(params)
+		var sk *rlwe.SecretKey
+		sk, pk = kgen.GenKeyPairNew()
+		rlk = kgen.GenRelinearizationKeyNew(sk)
+		decryptor = rlwe.NewDecryptor(params, sk)
+
+		fmt.Println("Generating bootstrapping evaluation keys...")
+		if evk_boot, _, err = btpParams.GenEvaluationKeys(sk); err != nil {
+			panic(err)
+		}
+	}
+	fmt.Println("Done")
+
+	encoder := hefloat.NewEncoder(params)
+	encryptor := rlwe.NewEncryptor(params, pk)
+
+	var eval_boot *bootstrapping.Evaluator
+	if eval_boot, err = bootstrapping.NewEvaluator(btpParams, evk_boot); err != nil {
+		panic(err)
+	}
+
+	eval := hefloat.NewEvaluator(params, rlwe.NewMemEvaluationKeySet(rlk))
+
+	// Client: encrypts one ciphertext per feature.
+	input := dataset.Transpose(rows)
+	input_ct := make([]*rlwe.Ciphertext, len(input))
+	pt := hefloat.NewPlaintext(params, params.MaxLevel())
+	for i := range input {
+		if err = encoder.Encode(input[i], pt); err != nil {
+			panic(err)
+		}
+		if input_ct[i], err = encryptor.EncryptNew(pt); err != nil {
+			panic(err)
+		}
+	}
+
+	// Server: evaluates the model, and the out-of-domain flag if asked.
+	var output_ct []*rlwe.Ciphertext
+	if *flagDomain {
+		var flag_ct *rlwe.Ciphertext
+		output_ct, flag_ct = m.ForwardFlagged(input_ct, eval, eval_boot, params)
+		output_ct = append(output_ct, flag_ct)
+	} else {
+		output_ct = m.Forward(input_ct, eval, eval_boot, params)
+	}
+	if *flagArgmax {
+		num := len(m.Layers[len(m.Layers)-1].Nodes)
+		output_ct = append(m.Argmax(output_ct[:num], eval, eval_boot, params), output_ct[num:]...)
+	}
+	if *flagDecide {
+		num := len(m.Layers[len(m.Layers)-1].Nodes)
+		threshold := *flagThreshold
+		if math.IsNaN(threshold) {
+			threshold = 0.5
+			if num == 2 {
+				threshold = 0
+			}
+		}
+		label_ct := m.Decide(output_ct[:num], threshold, eval, eval_boot, params)
+		output_ct = append([]*rlwe.Ciphertext{label_ct}, output_ct[num:]...)
+	}
+
+	// Server: floods the returned ciphertexts, keeping the exact ones to
+	// measure the precision cost, which only this simulation can do. The
+	// outputs take the error bound of the model on the declared feature
+	// ranges, the labels, one-hot vectors and flags that of a bootstrapping.
+	// The parties flood their decryption shares instead.
+	exact_ct := output_ct
+	var sigma []float64
+	if *flagFlood > 0 {
+		noise := src.NewNoise(params, math.Exp2(-*flagBootstrap))
+		bounds, errs := m.DeclaredBounds()
+		for _, err := range errs {
+			fmt.Println("warning:", err)
+		}
+		sigma = make([]float64, len(output_ct))
+		for i := range sigma {
+			sigma[i] = src.Flooding(noise.Bootstrap, *flagFlood)
+		}
+		if !*flagDecide && !*flagArgmax {
+			copy(sigma, m.FloodingNoise(noise, bounds.Pre, *flagFlood))
+		}
+		if session == nil {
+			exact_ct = make([]*rlwe.Ciphertext, len(output_ct))
+		}
+		for i := range output_ct {
+			if session == nil {
+				exact_ct[i] = output_ct[i].CopyNew()
+				if err = src.Flood(output_ct[i], sigma[i], params); err != nil {
+					panic(err)
+				}
+			}
+			fmt.Printf("Flooding ciphertext %d with noise of deviation %.3e for %d bits of security\n", i, sigma[i], *flagFlood)
+		}
+	}
+
+	// Client: decrypts the outputs, the parties with decryption shares
+	// flooded with noise of deviation sigma[i] on ciphertext i, none if nil.
+	decrypt := func(cts []*rlwe.Ciphertext, sigma []float64) (values [][]float64) {
+		values = make([][]float64, len(cts))
+		for i := range cts {
+			var pt *rlwe.Plaintext
+			if session != nil {
+				deviation := 0.0
+				if sigma != nil {
+					deviation = sigma[i]
+				}
+				if pt, err = session.Decrypt(cts[i], active, deviation); err != nil {
+					panic(err)
+				}
+			} else {
+				pt = decryptor.DecryptNew(cts[i])
+			}
+			values[i] = make([]float64, cts[i].Slots())
+			if err = encoder.Decode(pt, values[i]); err != nil {
+				panic(err)
+			}
+		}
+		return values
+	}
+	output := decrypt(output_ct, sigma)
+
+	// Client: rounds the values before publishing them.
+	if *flagRound >= 0 {
+		for j := range output {
+			for i := range rows {
+				output[j][i] = src.Round(output[j][i], *flagRound)
+			}
+		}
+	}
+	if *flagFlood > 0 || *flagRound >= 0 {
+		exact := decrypt(exact_ct, nil)
+		for j := range output {
+			cost := metrics.Summarize(metrics.NewDivergence(exact[j][:len(rows)], output[j][:len(rows)]).Absolute)
+			fmt.Printf("Precision cost on output %d: max %.3e, mean %.3e, %.2f bits left\n", j, cost.Max, cost.Mean, cost.MinBits)
+		}
+	}
+
+	// Client: rounds the labels, soft near the threshold. A label far from
+	// both 0 and 1 means the score left the range of the decision.
+	if *flagDecide {
+		soft := 0
+		for i := range rows {
+			v := output[0][i]
+			if !(v > -0.5 && v < 1.5) {
+				panic(fmt.Errorf("sample %d: decrypted label %g is neither 0 nor 1", i, v))
+			}
+			if v > 0.1 && v < 0.9 {
+				soft++
+			}
+			output[0][i] = 0
+			if v > 0.5 {
+				output[0][i] = 1
+			}
+		}
+		fmt.Printf("Decided %d samples, %d of them with a soft label\n", len(rows), soft)
+	}
+
+	if *flagDomain {
+		flagged := 0
+		for i := range rows {
+			if src.Flagged(output[len(output)-1][i]) {
+				flagged++
+			}
+		}
+		fmt.Printf("Flagged %d of %d samples out of domain\n", flagged, len(rows))
+	}
+
+	for i := range rows {
+		for j := range output {
+			if j != 0 {
+				fmt.Printf(",")
+			}
+			fmt.Printf("%.8f", output[j][i])
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,86 @@
+// Package main reports the metrics of a multi-class model: the class of a
+// sample is its largest output, or the hot entry of the one-hot vector
+// returned by an encrypted argmax, and the plaintext and decrypted predictions
+// are compared against the labels and against each other.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
+	"github.com/JohnJimAir/asimpnetwork/src"
+)
+
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of the samples, with a label column of classes 0, 1, ...")
+var flagPlain = flag.String("plain", "../../result/KAN_plaintext.csv", "plaintext outputs.")
+var flagCipher = flag.String("cipher", "../../result/KAN_ciphertext.csv", "decrypted outputs or one-hot vectors.")
+var flagOnehot = flag.Bool("onehot", false, "the decrypted file holds the one-hot vectors of infer -argmax, checked against -tolerance.")
+var flagTolerance = flag.Float64("tolerance", 0.5, "largest distance of a decrypted one-hot entry from 0 or 1 (with -onehot).")
+var flagOut = flag.String("out", "", "CSV file to write the plaintext and decrypted labels to, one sample per line.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features, Label: "label"})
+	if err != nil {
+		panic(err)
+	}
+	result_plain, err := dataset.ReadMatrix(*flagPlain)
+	if err != nil {
+		panic(err)
+	}
+	result_cipher, err := dataset.ReadMatrix(*flagCipher)
+	if err != nil {
+		panic(err)
+	}
+	if len(result_plain) != len(result_cipher) || len(result_plain) != data.Len() {
+		panic(fmt.Errorf("%d samples, %d plaintext and %d decrypted outputs", data.Len(), len(result_plain), len(result_cipher)))
+	}
+	result_plain = dataset.Transpose(result_plain)
+	result_cipher = dataset.Transpose(result_cipher)
+	classes := len(result_plain)
+	if len(result_cipher) != classes {
+		panic(fmt.Errorf("%d plaintext and %d decrypted outputs per sample", classes, len(result_cipher)))
+	}
+	for i, label := range data.Labels {
+		if label != float64(int(label)) || label < 0 || int(label) >= classes {
+			panic(fmt.Errorf("sample %d: label %g is not one of the %d classes", i, label, classes))
+		}
+	}
+
+	if *flagOnehot {
+		if err = metrics.CheckOneHot(result_cipher, *flagTolerance); err != nil {
+			panic(err)
+		}
+	}
+
+	label_plain := metrics.Argmax(result_plain)
+	label_cipher := metrics.Argmax(result_cipher)
+	matrix_plain := metrics.NewMatrix(label_plain, data.Labels, classes)
+	matrix_cipher := metrics.NewMatrix(label_cipher, data.Labels, classes)
+
+	metrics.WriteClassReports(os.Stdout, []string{"plaintext", "ciphertext"}, matrix_plain, matrix_cipher)
+	fmt.Println()
+	fmt.Println("plaintext")
+	metrics.WriteMatrix(os.Stdout, matrix_plain)
+	fmt.Println()
+	fmt.Println("ciphertext")
+	metrics.WriteMatrix(os.Stdout, matrix_cipher)
+	fmt.Println()
+	fmt.Printf("flipped %v\n", metrics.Flipped(label_plain, label_cipher))
+
+	if *flagOut != "" {
+		if err = dataset.WriteMatrix(*flagOut, dataset.Transpose([][]float64{label_plain, label_cipher})); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package main optimizes the graph of a model, reports what the optimization
+// saves and checks on a dataset that the outputs are unchanged.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/src"
+)
+
+var flagModel = flag.String("model", "breast-cancer", "built-in model name or model file.")
+var flagData = flag.String("data", "../../data/test_data_breast-cancer.csv", "CSV file of samples to check the optimized model on, none if empty.")
+var flagFold = flag.Bool("fold", false, "fold the edge weights into the polynomials of their producers.")
+var flagShare = flag.Bool("share", false, "also let the first layer nodes on a feature share their power basis, over the declared feature ranges; the polynomials are fit anew, so the outputs change by their approximation error.")
+var flagLevels = flag.Int("levels", 10, "levels of fresh and bootstrapped ciphertexts the strategies plan on, 10 in the parameters of the examples.")
+var flagOut = flag.String("out", "", "write the optimized model to this file.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	o := m.Optimize()
+	if *flagFold {
+		o = o.FoldWeights()
+	}
+	if *flagShare {
+		if o.Ranges == nil {
+			panic("-share needs the feature ranges declared by the model")
+		}
+		o = o.ShareInputs(o.Ranges)
+	}
+	if err = o.Check(); err != nil {
+		panic(err)
+	}
+
+	before, after := m.Cost(*flagLevels), o.Cost(*flagLevels)
+	row := func(label string, b, a int) {
+		fmt.Printf("%-12s %8d %8d %8d\n", label, b, a, b-a)
+	}
+	fmt.Printf("%-12s %8s %8s %8s\n", "", "before", "after", "saved")
+	row("layers", before.Layers, after.Layers)
+	row("inputs", before.Inputs, after.Inputs)
+	row("nodes", before.Nodes, after.Nodes)
+	row("bases", before.Bases, after.Bases)
+	row("edges", before.Edges, after.Edges)
+	row("levels", before.Levels, after.Levels)
+	row("bootstraps", before.Bootstraps, after.Bootstraps)
+
+	if *flagData != "" {
+		data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features})
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("\nlargest relative change of the outputs on %d samples: %.3e\n", data.Len(), m.Equivalent(o, data.Rows))
+	}
+
+	if *flagOut != "" {
+		if err = src.WriteModel(*flagOut, o); err != nil {
+			panic(err)
+		}
+	}
+}
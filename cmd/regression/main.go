@@ -0,0 +1,78 @@
+// Package main reports the metrics of a regression model: the errors of its
+// plaintext and decrypted predictions against the targets, and how far the
+// decrypted predictions are from the plaintext ones, sample by sample.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/JohnJimAir/asimpnetwork/metrics"
+	"github.com/JohnJimAir/asimpnetwork/src"
+)
+
+var flagModel = flag.String("model", "", "regression model file.")
+var flagData = flag.String("data", "", "CSV file of the samples, with a column of targets.")
+var flagTarget = flag.String("target", "label", "column of the targets.")
+var flagOutput = flag.Int("output", 0, "output of the model that predicts the target.")
+var flagPlain = flag.String("plain", "../../result/KAN_plaintext.csv", "plaintext outputs.")
+var flagCipher = flag.String("cipher", "../../result/KAN_ciphertext.csv", "decrypted outputs.")
+var flagSamples = flag.Bool("samples", false, "print the predictions and their divergence for every sample.")
+
+func main() {
+
+	flag.Parse()
+
+	m, err := src.LoadModel(*flagModel)
+	if err != nil {
+		panic(err)
+	}
+	if !m.IsRegression() {
+		panic(fmt.Errorf("model %q is a classifier, its task is not %q", *flagModel, src.Regression))
+	}
+	data, err := dataset.Load(*flagData, dataset.Schema{Features: m.Features, Label: *flagTarget})
+	if err != nil {
+		panic(err)
+	}
+	result_plain, err := dataset.ReadMatrix(*flagPlain)
+	if err != nil {
+		panic(err)
+	}
+	result_cipher, err := dataset.ReadMatrix(*flagCipher)
+	if err != nil {
+		panic(err)
+	}
+	if len(result_plain) != len(result_cipher) || len(result_plain) != data.Len() {
+		panic(fmt.Errorf("%d samples, %d plaintext and %d decrypted outputs", data.Len(), len(result_plain), len(result_cipher)))
+	}
+	result_plain = dataset.Transpose(result_plain)
+	result_cipher = dataset.Transpose(result_cipher)
+	if *flagOutput < 0 || *flagOutput >= len(result_plain) || *flagOutput >= len(result_cipher) {
+		panic(fmt.Errorf("output %d of %d plaintext and %d decrypted outputs per sample", *flagOutput, len(result_plain), len(result_cipher)))
+	}
+	predicted_plain := result_plain[*flagOutput]
+	predicted_cipher := result_cipher[*flagOutput]
+	target := data.Labels
+
+	metrics.WriteRegressionReports(os.Stdout,
+		metrics.NewRegressionReport("plaintext", predicted_plain, target),
+		metrics.NewRegressionReport("ciphertext", predicted_cipher, target),
+	)
+
+	// Divergence of the decrypted predictions from the plaintext ones.
+	divergence := metrics.NewDivergence(predicted_plain, predicted_cipher)
+	abs := metrics.Summarize(divergence.Absolute)
+	fmt.Println()
+	fmt.Printf("%-10s %12s %12s %12s %12s %12s %10s %10s\n", "divergence", "max", "mean", "p50", "p90", "p99", "min bits", "mean bits")
+	fmt.Printf("%-10s %12.4e %12.4e %12.4e %12.4e %12.4e %10.2f %10.2f\n", "", abs.Max, abs.Mean, abs.P50, abs.P90, abs.P99, abs.MinBits, abs.MeanBits)
+
+	if *flagSamples {
+		fmt.Println()
+		fmt.Printf("%-8s %14s %14s %14s %12s\n", "sample", "target", "plain", "cipher", "divergence")
+		for i := range target {
+			fmt.Printf("%-8d %14.8f %14.8f %14.8f %12.4e\n", i, target[i], predicted_plain[i], predicted_cipher[i], divergence.Absolute[i])
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Dataset holds the samples of a CSV file, with the features reordered to
+// follow the model inputs declared by Schema.
+type Dataset struct {
+	Schema Schema
+	Rows   [][]float64 // row-major: Rows[sample][feature]
+	Labels []float64   // nil if Schema.Label is empty
+}
+
+// Len returns the number of samples.
+func (d *Dataset) Len() int {
+	return len(d.Rows)
+}
+
+// Columns returns the column-major view: Columns()[feature][sample]. This is
+// the layout that is encoded into one ciphertext per feature.
+func (d *Dataset) Columns() [][]float64 {
+	columns := make([][]float64, len(d.Schema.Features))
+	for j := range columns {
+		columns[j] = make([]float64, len(d.Rows))
+		for i := range d.Rows {
+			columns[j][i] = d.Rows[i][j]
+		}
+	}
+	return columns
+}
+
+// Column returns the values of the named feature.
+func (d *Dataset) Column(feature string) ([]float64, error) {
+	j := d.Schema.Index(feature)
+	if j < 0 {
+		return nil, fmt.Errorf("dataset: no feature %q in schema", feature)
+	}
+	column := make([]float64, len(d.Rows))
+	for i := range d.Rows {
+		column[i] = d.Rows[i][j]
+	}
+	return column, nil
+}
+
+// ParseError reports a malformed cell or row of a CSV file.
+type ParseError struct {
+	File   string
+	Line   int
+	Column string // empty when the whole row is malformed
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: column %q: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors collects every malformed row of a file, so that a broken export
+// can be fixed in one pass.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	lines := make([]string, len(e))
+	for i := range e {
+		lines[i] = e[i].Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Load reads a CSV file whose first row is a header. Columns are matched to
+// the schema by name; columns not named by the schema are ignored. Every
+// malformed row is reported with its line number.
+func Load(filename string, schema Schema) (*Dataset, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: empty file", filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	position := make(map[string]int, len(header))
+	for j, name := range header {
+		position[strings.TrimSpace(name)] = j
+	}
+
+	index := make([]int, len(schema.Features))
+	var missing []string
+	for i, name := range schema.Features {
+		j, ok := position[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		index[i] = j
+	}
+	label := -1
+	if schema.Label != "" {
+		j, ok := position[schema.Label]
+		if !ok {
+			missing = append(missing, schema.Label)
+		}
+		label = j
+	}
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("%s: missing columns %s", filename, strings.Join(missing, ", "))
+	}
+
+	d := &Dataset{Schema: schema}
+	if label >= 0 {
+		d.Labels = make([]float64, 0)
+	}
+
+	var errs ParseErrors
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, &ParseError{File: filename, Line: errorLine(err), Err: err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			errs = append(errs, &ParseError{File: filename, Line: line, Err: fmt.Errorf("got %d fields, header has %d", len(record), len(header))})
+			continue
+		}
+
+		row := make([]float64, len(index))
+		ok := true
+		for i, j := range index {
+			if row[i], err = parseCell(record[j]); err != nil {
+				errs = append(errs, &ParseError{File: filename, Line: line, Column: header[j], Err: err})
+				ok = false
+			}
+		}
+		var y float64
+		if label >= 0 {
+			if y, err = parseCell(record[label]); err != nil {
+				errs = append(errs, &ParseError{File: filename, Line: line, Column: header[label], Err: err})
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		d.Rows = append(d.Rows, row)
+		if label >= 0 {
+			d.Labels = append(d.Labels, y)
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	return d, nil
+}
+
+// ReadMatrix reads a header-less numeric CSV file, such as the prediction
+// files in result/, into a row-major matrix.
+func ReadMatrix(filename string) ([][]float64, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var data [][]float64
+	var errs ParseErrors
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, &ParseError{File: filename, Line: errorLine(err), Err: err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(data) != 0 && len(record) != len(data[0]) {
+			errs = append(errs, &ParseError{File: filename, Line: line, Err: fmt.Errorf("got %d fields, expected %d", len(record), len(data[0]))})
+			continue
+		}
+
+		row := make([]float64, len(record))
+		ok := true
+		for j := range record {
+			if row[j], err = parseCell(record[j]); err != nil {
+				errs = append(errs, &ParseError{File: filename, Line: line, Column: strconv.Itoa(j), Err: err})
+				ok = false
+			}
+		}
+		if ok {
+			data = append(data, row)
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	return data, nil
+}
+
+// WriteMatrix writes a row-major matrix as a header-less numeric CSV file,
+// which ReadMatrix reads back. Integers, such as labels and one-hot vectors,
+// are written without decimals.
+func WriteMatrix(filename string, data [][]float64) error {
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	for _, row := range data {
+		record := make([]string, len(row))
+		for j, value := range row {
+			record[j] = strconv.FormatFloat(value, 'g', -1, 64)
+		}
+		if err = writer.Write(record); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseCell reads a numeric cell. Empty and "NA" cells are missing values and
+// are returned as NaN, to be filled by an imputation step.
+func parseCell(cell string) (float64, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" || cell == "NA" {
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(cell, 64)
+}
+
+func errorLine(err error) int {
+	var csvErr *csv.ParseError
+	if errors.As(err, &csvErr) {
+		return csvErr.Line
+	}
+	return 0
+}
+
+// Transpose switches between the row-major and the column-major layout.
+func Transpose(data [][]float64) [][]float64 {
+	if len(data) == 0 {
+		return nil
+	}
+
+	rows := len(data)
+	cols := len(data[0])
+
+	transposed := make([][]float64, cols)
+	for i := range transposed {
+		transposed[i] = make([]float64, rows)
+	}
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			transposed[j][i] = data[i][j]
+		}
+	}
+
+	return transposed
+}
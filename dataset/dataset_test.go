@@ -0,0 +1,125 @@
+package dataset
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// write writes content to a file of the test directory and returns its path.
+func write(t *testing.T, name, content string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+
+	schema := Schema{Features: []string{"a", "b", "c"}, Label: "label"}
+	nan := math.NaN()
+	for _, tc := range []struct {
+		name    string
+		content string
+		rows    [][]float64
+		labels  []float64
+		line    int // of the first parse error, 0 if none
+		fails   bool
+	}{
+		{
+			"in the order of the schema",
+			"a,b,c,label\n1,2,3,0\n4,5,6,1\n",
+			[][]float64{{1, 2, 3}, {4, 5, 6}}, []float64{0, 1}, 0, false,
+		},
+		{
+			"reordered headers and an ignored column",
+			"label, c,extra,a,b\n0,3,9,1,2\n1,6,9,4,5\n",
+			[][]float64{{1, 2, 3}, {4, 5, 6}}, []float64{0, 1}, 0, false,
+		},
+		{
+			"missing values",
+			"a,b,c,label\n1,,3,0\nNA,5, NA ,1\n",
+			[][]float64{{1, nan, 3}, {nan, 5, nan}}, []float64{0, 1}, 0, false,
+		},
+		{
+			"missing column",
+			"a,c,label\n1,3,0\n",
+			nil, nil, 0, true,
+		},
+		{
+			"unparsable cell",
+			"a,b,c,label\n1,2,3,0\n4,5,6,1\n7,x,9,0\n",
+			nil, nil, 4, true,
+		},
+		{
+			"short row",
+			"a,b,c,label\n1,2,3,0\n4,5,1\n",
+			nil, nil, 3, true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := Load(write(t, "data.csv", tc.content), schema)
+			if tc.fails {
+				if err == nil {
+					t.Fatal("loaded")
+				}
+				var errs ParseErrors
+				if tc.line != 0 && (!errors.As(err, &errs) || errs[0].Line != tc.line) {
+					t.Fatalf("error %v, want one at line %d", err, tc.line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !same(d.Rows, tc.rows) || !reflect.DeepEqual(d.Labels, tc.labels) {
+				t.Fatalf("rows %v and labels %v, want %v and %v", d.Rows, d.Labels, tc.rows, tc.labels)
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+
+	data := [][]float64{{0, 1, -2.5}, {1e-17, 0.1, 3}, {12345, -7, 1.0 / 3}}
+	filename := filepath.Join(t.TempDir(), "matrix.csv")
+	if err := WriteMatrix(filename, data); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadMatrix(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same(got, data) {
+		t.Fatalf("read %v, wrote %v", got, data)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0,1,-2.5\n1e-17,0.1,3\n12345,-7,0.3333333333333333\n"; string(content) != want {
+		t.Fatalf("wrote %q, want %q", content, want)
+	}
+}
+
+// same reports whether a and b are equal, NaN matching NaN.
+func same(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] && !(math.IsNaN(a[i][j]) && math.IsNaN(b[i][j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
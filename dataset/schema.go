@@ -0,0 +1,40 @@
+package dataset
+
+// Schema names the CSV columns a model consumes. Features are listed in the
+// order of the model inputs, so x_1 of a model is Features[0] whatever the
+// position of that column in the exported file.
+type Schema struct {
+	Features []string
+	Label    string // empty if the file carries no label column
+}
+
+// Index returns the model input index of the named feature, or -1.
+func (s Schema) Index(feature string) int {
+	for i, name := range s.Features {
+		if name == feature {
+			return i
+		}
+	}
+	return -1
+}
+
+// BreastCancer is the schema of data/test_data_breast-cancer.csv.
+var BreastCancer = Schema{
+	Features: []string{
+		"clump_thickness", "size_uniformity", "shape_uniformity",
+		"marginal_adhesion", "epithelial_size", "bare_nucleoli",
+		"bland_chromatin", "normal_nucleoli", "mitoses",
+	},
+	Label: "label",
+}
+
+// Sepsis is the schema of data/test_data_sepsis.csv.
+var Sepsis = Schema{
+	Features: []string{
+		"sex", "age", "T", "imp", "type", "site", "ANA", "ASA", "EMR", "BMI",
+		"Chemo", "EH", "DM", "HD", "COPD", "KD", "CRP", "Ca", "IL-6", "MPV",
+		"PDW", "ALB", "GLO", "AGR", "AST", "ALT", "TBIL", "K+", "Cr", "Glu",
+		"WBC", "PLT", "Hb", "PCT", "NC", "LC", "NLCR",
+	},
+	Label: "label",
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Divergence holds the per-sample errors of decrypted outputs against their
+// plaintext reference.
+type Divergence struct {
+	Absolute []float64
+	Relative []float64 // absolute error over |reference|, Inf if the reference is 0
+}
+
+// NewDivergence compares have to the reference want.
+func NewDivergence(want, have []float64) (d Divergence) {
+	d.Absolute = make([]float64, len(want))
+	d.Relative = make([]float64, len(want))
+	for i := range want {
+		d.Absolute[i] = math.Abs(have[i] - want[i])
+		d.Relative[i] = d.Absolute[i] / math.Abs(want[i])
+	}
+	return d
+}
+
+// Summary gives the distribution of a set of errors.
+type Summary struct {
+	Max, Mean         float64
+	P50, P90, P99     float64
+	MinBits, MeanBits float64 // -log2 of the max and of the mean error
+}
+
+// Summarize computes the summary statistics of errors, all NaN if there are
+// none, as Percentile.
+func Summarize(errors []float64) (s Summary) {
+	if len(errors) == 0 {
+		nan := math.NaN()
+		return Summary{nan, nan, nan, nan, nan, nan, nan}
+	}
+	sorted := append([]float64(nil), errors...)
+	sort.Float64s(sorted)
+	for _, e := range sorted {
+		s.Mean += e
+	}
+	s.Mean /= float64(len(sorted))
+	s.Max = sorted[len(sorted)-1]
+	s.P50 = Percentile(sorted, 50)
+	s.P90 = Percentile(sorted, 90)
+	s.P99 = Percentile(sorted, 99)
+	s.MinBits = -math.Log2(s.Max)
+	s.MeanBits = -math.Log2(s.Mean)
+	return s
+}
+
+// Percentile returns the p-th percentile of sorted values, interpolating
+// linearly between ranks.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (rank-float64(lo))*(sorted[hi]-sorted[lo])
+}
+
+// Flipped returns the indices of the samples whose labels differ.
+func Flipped(labels_0, labels_1 []float64) (indices []int) {
+	for i := range labels_0 {
+		if labels_0[i] != labels_1[i] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+
+	for _, tc := range []struct {
+		name   string
+		errors []float64
+		want   Summary
+	}{
+		{"single", []float64{0.25}, Summary{0.25, 0.25, 0.25, 0.25, 0.25, 2, 2}},
+		{"unsorted", []float64{4, 1, 3, 2}, Summary{4, 2.5, 2.5, 3.7, 3.97, -2, -math.Log2(2.5)}},
+		{"exact", []float64{0, 0}, Summary{0, 0, 0, 0, 0, math.Inf(1), math.Inf(1)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Summarize(tc.errors)
+			g := []float64{got.Max, got.Mean, got.P50, got.P90, got.P99, got.MinBits, got.MeanBits}
+			w := []float64{tc.want.Max, tc.want.Mean, tc.want.P50, tc.want.P90, tc.want.P99, tc.want.MinBits, tc.want.MeanBits}
+			for k := range g {
+				if !(g[k] == w[k] || math.Abs(g[k]-w[k]) < 1e-12) {
+					t.Fatalf("got %+v, want %+v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		s := Summarize(nil)
+		for _, v := range []float64{s.Max, s.Mean, s.P50, s.P90, s.P99, s.MinBits, s.MeanBits} {
+			if !math.IsNaN(v) {
+				t.Fatalf("got %+v, want NaN", s)
+			}
+		}
+	})
+}
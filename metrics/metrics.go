@@ -0,0 +1,197 @@
+// Package metrics evaluates binary classifiers from their scores, multi-class
+// classifiers from their outputs and regression models from their
+// predictions, so that the plaintext and the decrypted predictions of a model
+// can be compared with the same numbers.
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Confusion is the confusion matrix of binary predictions.
+type Confusion struct {
+	TP, FP, TN, FN int
+}
+
+// NewConfusion counts the 0/1 predictions against the 0/1 true labels.
+func NewConfusion(predicted, truth []float64) (c Confusion) {
+	for i := range predicted {
+		switch {
+		case predicted[i] == 1 && truth[i] == 1:
+			c.TP++
+		case predicted[i] == 1:
+			c.FP++
+		case truth[i] == 1:
+			c.FN++
+		default:
+			c.TN++
+		}
+	}
+	return c
+}
+
+// Total returns the number of samples.
+func (c Confusion) Total() int {
+	return c.TP + c.FP + c.TN + c.FN
+}
+
+// Accuracy returns (TP + TN) / total.
+func (c Confusion) Accuracy() float64 {
+	return ratio(c.TP+c.TN, c.Total())
+}
+
+// Precision returns TP / (TP + FP), or NaN if nothing is predicted positive.
+func (c Confusion) Precision() float64 {
+	return ratio(c.TP, c.TP+c.FP)
+}
+
+// Recall returns the sensitivity TP / (TP + FN), or NaN without positives.
+func (c Confusion) Recall() float64 {
+	return ratio(c.TP, c.TP+c.FN)
+}
+
+// Specificity returns TN / (TN + FP), or NaN without negatives.
+func (c Confusion) Specificity() float64 {
+	return ratio(c.TN, c.TN+c.FP)
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (c Confusion) F1() float64 {
+	return ratio(2*c.TP, 2*c.TP+c.FP+c.FN)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return float64(a) / float64(b)
+}
+
+// Threshold is the decision rule of the models: a sample is positive when its
+// score is strictly greater than t.
+func Threshold(scores []float64, t float64) (labels []float64) {
+	labels = make([]float64, len(scores))
+	for i := range scores {
+		if scores[i] > t {
+			labels[i] = 1
+		}
+	}
+	return labels
+}
+
+// Margin returns input_1 - input_0, the score of a model whose two outputs are
+// compared: Threshold(Margin(a, b), 0) gives the same labels as picking the
+// class of the larger output.
+func Margin(input_0, input_1 []float64) (scores []float64) {
+	scores = make([]float64, len(input_0))
+	for i := range input_0 {
+		scores[i] = input_1[i] - input_0[i]
+	}
+	return scores
+}
+
+// ROC returns the false and true positive rates of Threshold(scores, t) for
+// every distinct score t, from the highest threshold to the lowest. The first
+// point is (0, 0) and the last is (1, 1).
+func ROC(scores, truth []float64) (fpr, tpr, thresholds []float64) {
+
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	positives, negatives := 0, 0
+	for i := range truth {
+		if truth[i] == 1 {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+
+	fpr = []float64{0}
+	tpr = []float64{0}
+	thresholds = []float64{math.Inf(1)}
+
+	tp, fp := 0, 0
+	for k := 0; k < len(order); {
+		t := scores[order[k]]
+		for ; k < len(order) && scores[order[k]] == t; k++ {
+			if truth[order[k]] == 1 {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		fpr = append(fpr, ratio(fp, negatives))
+		tpr = append(tpr, ratio(tp, positives))
+		thresholds = append(thresholds, t)
+	}
+	return fpr, tpr, thresholds
+}
+
+// AUC returns the area under the ROC curve, ties counting for one half.
+func AUC(scores, truth []float64) (auc float64) {
+	fpr, tpr, _ := ROC(scores, truth)
+	for i := 1; i < len(fpr); i++ {
+		auc += (fpr[i] - fpr[i-1]) * (tpr[i] + tpr[i-1]) / 2
+	}
+	return auc
+}
+
+// CalibrationError returns the expected calibration error of probabilities
+// over equal-width bins of [0, 1]: the mean over samples of the gap between
+// the average predicted probability and the observed frequency of positives
+// in their bin. Probabilities outside [0, 1] are clipped.
+func CalibrationError(probabilities, truth []float64, bins int) (ece float64) {
+
+	count := make([]int, bins)
+	sum_p := make([]float64, bins)
+	sum_y := make([]float64, bins)
+	for i := range probabilities {
+		p := math.Min(math.Max(probabilities[i], 0), 1)
+		b := int(p * float64(bins))
+		if b == bins {
+			b--
+		}
+		count[b]++
+		sum_p[b] += p
+		sum_y[b] += truth[i]
+	}
+
+	for b := 0; b < bins; b++ {
+		if count[b] != 0 {
+			ece += math.Abs(sum_p[b]-sum_y[b]) / float64(len(probabilities))
+		}
+	}
+	return ece
+}
+
+// SweepPoint is the confusion matrix of one decision threshold.
+type SweepPoint struct {
+	Threshold float64
+	Confusion
+}
+
+// Sweep returns the confusion matrix at each threshold.
+func Sweep(scores, truth, thresholds []float64) (points []SweepPoint) {
+	points = make([]SweepPoint, len(thresholds))
+	for i, t := range thresholds {
+		points[i] = SweepPoint{Threshold: t, Confusion: NewConfusion(Threshold(scores, t), truth)}
+	}
+	return points
+}
+
+// Linspace returns num evenly spaced thresholds from start to stop.
+func Linspace(start, stop float64, num int) (values []float64) {
+	values = make([]float64, num)
+	for i := range values {
+		values[i] = start
+		if num > 1 {
+			values[i] += (stop - start) * float64(i) / float64(num-1)
+		}
+	}
+	return values
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// same reports whether got and want are equal up to rounding, NaN included.
+func same(got, want float64) bool {
+	return got == want || math.Abs(got-want) < 1e-12 || math.IsNaN(got) && math.IsNaN(want)
+}
+
+func TestConfusion(t *testing.T) {
+
+	nan := math.NaN()
+	for _, tc := range []struct {
+		name                                  string
+		predicted, truth                      []float64
+		want                                  Confusion
+		accuracy, precision, recall, spec, f1 float64
+	}{
+		{
+			"mixed",
+			[]float64{1, 1, 1, 0, 0, 0, 0, 1},
+			[]float64{1, 1, 0, 0, 0, 1, 0, 1},
+			Confusion{TP: 3, FP: 1, TN: 3, FN: 1},
+			6.0 / 8, 3.0 / 4, 3.0 / 4, 3.0 / 4, 3.0 / 4,
+		},
+		{
+			"perfect",
+			[]float64{1, 0, 1},
+			[]float64{1, 0, 1},
+			Confusion{TP: 2, TN: 1},
+			1, 1, 1, 1, 1,
+		},
+		{
+			"nothing predicted positive",
+			[]float64{0, 0, 0, 0},
+			[]float64{1, 0, 0, 1},
+			Confusion{TN: 2, FN: 2},
+			0.5, nan, 0, 1, 0,
+		},
+		{
+			"no negatives",
+			[]float64{1, 0},
+			[]float64{1, 1},
+			Confusion{TP: 1, FN: 1},
+			0.5, 1, 0.5, nan, 2.0 / 3,
+		},
+		{
+			"empty",
+			nil,
+			nil,
+			Confusion{},
+			nan, nan, nan, nan, nan,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewConfusion(tc.predicted, tc.truth)
+			if c != tc.want {
+				t.Fatalf("got %+v, want %+v", c, tc.want)
+			}
+			if c.Total() != len(tc.predicted) {
+				t.Errorf("total %d of %d samples", c.Total(), len(tc.predicted))
+			}
+			for _, m := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"accuracy", c.Accuracy(), tc.accuracy},
+				{"precision", c.Precision(), tc.precision},
+				{"recall", c.Recall(), tc.recall},
+				{"specificity", c.Specificity(), tc.spec},
+				{"F1", c.F1(), tc.f1},
+			} {
+				if !same(m.got, m.want) {
+					t.Errorf("%s %g, want %g", m.name, m.got, m.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAUC(t *testing.T) {
+
+	for _, tc := range []struct {
+		name          string
+		scores, truth []float64
+		want          float64
+	}{
+		{"separated", []float64{0.9, 0.8, 0.3, 0.1}, []float64{1, 1, 0, 0}, 1},
+		{"reversed", []float64{0.1, 0.2, 0.8, 0.9}, []float64{1, 1, 0, 0}, 0},
+		{"one swap", []float64{0.9, 0.7, 0.8, 0.1}, []float64{1, 1, 0, 0}, 0.75},
+		{"ties", []float64{0.5, 0.5, 0.5, 0.5}, []float64{1, 0, 1, 0}, 0.5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AUC(tc.scores, tc.truth); !same(got, tc.want) {
+				t.Fatalf("AUC %g, want %g", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestThreshold(t *testing.T) {
+
+	scores := []float64{-1, 0, 0.5, 2}
+	for _, tc := range []struct {
+		t    float64
+		want []float64
+	}{
+		{0, []float64{0, 0, 1, 1}},
+		{0.5, []float64{0, 0, 0, 1}},
+		{-2, []float64{1, 1, 1, 1}},
+	} {
+		got := Threshold(scores, tc.t)
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("threshold %g: got %v, want %v", tc.t, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestCalibrationError(t *testing.T) {
+
+	for _, tc := range []struct {
+		name                 string
+		probabilities, truth []float64
+		want                 float64
+	}{
+		{"calibrated", []float64{0.25, 0.25, 0.25, 0.25}, []float64{1, 0, 0, 0}, 0},
+		{"confident and wrong", []float64{1, 1, 0, 0}, []float64{0, 0, 1, 1}, 1},
+		{"clipped", []float64{1.5, -0.5}, []float64{1, 0}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CalibrationError(tc.probabilities, tc.truth, 10); !same(got, tc.want) {
+				t.Fatalf("ECE %g, want %g", got, tc.want)
+			}
+		})
+	}
+}
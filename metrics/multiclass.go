@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Argmax returns, per sample, the index of the largest output, given one
+// slice per output: the class predicted by a multi-class model, or encoded by
+// a decrypted one-hot vector. Ties go to the lowest index.
+func Argmax(outputs [][]float64) (labels []float64) {
+	labels = make([]float64, len(outputs[0]))
+	for i := range labels {
+		best := 0
+		for j := range outputs {
+			if outputs[j][i] > outputs[best][i] {
+				best = j
+			}
+		}
+		labels[i] = float64(best)
+	}
+	return labels
+}
+
+// OneHot returns the one-hot encoding of the labels in 0, ..., classes-1, one
+// slice per class as Argmax reads them.
+func OneHot(labels []float64, classes int) (outputs [][]float64) {
+	outputs = make([][]float64, classes)
+	for j := range outputs {
+		outputs[j] = make([]float64, len(labels))
+	}
+	for i, label := range labels {
+		outputs[int(label)][i] = 1
+	}
+	return outputs
+}
+
+// CheckOneHot verifies that every entry of the decrypted one-hot vectors,
+// given one slice per class, is within tolerance of 0 or 1, as Argmax reads
+// them; an entry far from both means the outputs left the range of the
+// encrypted argmax.
+func CheckOneHot(outputs [][]float64, tolerance float64) error {
+	for j := range outputs {
+		for i, v := range outputs[j] {
+			if !(math.Min(math.Abs(v), math.Abs(v-1)) <= tolerance) {
+				return fmt.Errorf("sample %d: one-hot entry %d is %g, not within %g of 0 or 1", i, j, v, tolerance)
+			}
+		}
+	}
+	return nil
+}
+
+// Matrix is the confusion matrix of multi-class predictions: Matrix[t][p]
+// counts the samples of true class t predicted as p.
+type Matrix [][]int
+
+// NewMatrix counts the predictions against the true labels, both in
+// 0, ..., classes-1.
+func NewMatrix(predicted, truth []float64, classes int) Matrix {
+	m := make(Matrix, classes)
+	for t := range m {
+		m[t] = make([]int, classes)
+	}
+	for i := range predicted {
+		m[int(truth[i])][int(predicted[i])]++
+	}
+	return m
+}
+
+// Total returns the number of samples.
+func (m Matrix) Total() (total int) {
+	for t := range m {
+		for p := range m[t] {
+			total += m[t][p]
+		}
+	}
+	return total
+}
+
+// Accuracy returns the fraction of samples on the diagonal.
+func (m Matrix) Accuracy() float64 {
+	correct := 0
+	for c := range m {
+		correct += m[c][c]
+	}
+	return ratio(correct, m.Total())
+}
+
+// Precision returns the fraction of the samples predicted as class c that
+// are of class c, or NaN if none is.
+func (m Matrix) Precision(c int) float64 {
+	predicted := 0
+	for t := range m {
+		predicted += m[t][c]
+	}
+	return ratio(m[c][c], predicted)
+}
+
+// Recall returns the fraction of the samples of class c predicted as such,
+// or NaN without samples of class c.
+func (m Matrix) Recall(c int) float64 {
+	actual := 0
+	for p := range m[c] {
+		actual += m[c][p]
+	}
+	return ratio(m[c][c], actual)
+}
+
+// F1 returns the harmonic mean of the precision and recall of class c.
+func (m Matrix) F1(c int) float64 {
+	predicted, actual := 0, 0
+	for k := range m {
+		predicted += m[k][c]
+		actual += m[c][k]
+	}
+	return ratio(2*m[c][c], predicted+actual)
+}
+
+// MacroF1 returns the mean F1 of the classes that occur in the truth or the
+// predictions.
+func (m Matrix) MacroF1() float64 {
+	sum, classes := 0.0, 0
+	for c := range m {
+		if f1 := m.F1(c); !math.IsNaN(f1) {
+			sum += f1
+			classes++
+		}
+	}
+	if classes == 0 {
+		return math.NaN()
+	}
+	return sum / float64(classes)
+}
+
+// WriteMatrix prints the confusion matrix, one row per true class.
+func WriteMatrix(w io.Writer, m Matrix) {
+	fmt.Fprintf(w, "%-10s", "true\\pred")
+	for p := range m {
+		fmt.Fprintf(w, "%8d", p)
+	}
+	fmt.Fprintln(w)
+	for t := range m {
+		fmt.Fprintf(w, "%-10d", t)
+		for p := range m[t] {
+			fmt.Fprintf(w, "%8d", m[t][p])
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// WriteClassReports prints the accuracy, macro F1 and the per-class
+// precision, recall and F1 of several confusion matrices side by side, one
+// column per matrix.
+func WriteClassReports(w io.Writer, names []string, matrices ...Matrix) {
+
+	row := func(label string, value func(m Matrix) float64) {
+		fmt.Fprintf(w, "%-14s", label)
+		for _, m := range matrices {
+			if x := value(m); math.IsNaN(x) {
+				fmt.Fprintf(w, "%14s", "-")
+			} else {
+				fmt.Fprintf(w, "%14.4f", x)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%-14s", "")
+	for _, name := range names {
+		fmt.Fprintf(w, "%14s", name)
+	}
+	fmt.Fprintln(w)
+	row("accuracy", Matrix.Accuracy)
+	row("macro F1", Matrix.MacroF1)
+	for c := range matrices[0] {
+		row(fmt.Sprintf("precision %d", c), func(m Matrix) float64 { return m.Precision(c) })
+		row(fmt.Sprintf("recall %d", c), func(m Matrix) float64 { return m.Recall(c) })
+		row(fmt.Sprintf("F1 %d", c), func(m Matrix) float64 { return m.F1(c) })
+	}
+}
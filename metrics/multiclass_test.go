@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestArgmax(t *testing.T) {
+
+	for _, tc := range []struct {
+		name    string
+		outputs [][]float64
+		want    []float64
+	}{
+		{"largest", [][]float64{{0.1, 3, -1}, {0.5, 2, -2}, {0.2, 1, -0.5}}, []float64{1, 0, 2}},
+		{"ties to the lowest", [][]float64{{1, 0}, {1, 0}}, []float64{0, 0}},
+		{"one-hot round trip", OneHot([]float64{2, 0, 1}, 3), []float64{2, 0, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Argmax(tc.outputs); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckOneHot(t *testing.T) {
+
+	for _, tc := range []struct {
+		name    string
+		outputs [][]float64
+		ok      bool
+	}{
+		{"exact", [][]float64{{1, 0}, {0, 1}}, true},
+		{"within tolerance", [][]float64{{0.9, -0.2}, {0.1, 1.3}}, true},
+		{"halfway", [][]float64{{0.5}, {0.5}}, true},
+		{"beyond tolerance", [][]float64{{1.6}, {0}}, false},
+		{"overflowed", [][]float64{{1e17}, {0}}, false},
+		{"NaN", [][]float64{{math.NaN()}, {1}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckOneHot(tc.outputs, 0.5); (err == nil) != tc.ok {
+				t.Fatalf("error %v", err)
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+
+	// Three classes, with class 2 never predicted.
+	predicted := []float64{0, 0, 1, 1, 1, 0, 1}
+	truth := []float64{0, 0, 1, 1, 0, 2, 2}
+	m := NewMatrix(predicted, truth, 3)
+	if want := (Matrix{{2, 1, 0}, {0, 2, 0}, {1, 1, 0}}); !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %v, want %v", m, want)
+	}
+	nan := math.NaN()
+	for _, tc := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"total", float64(m.Total()), 7},
+		{"accuracy", m.Accuracy(), 4.0 / 7},
+		{"precision 0", m.Precision(0), 2.0 / 3},
+		{"precision 1", m.Precision(1), 2.0 / 4},
+		{"precision 2", m.Precision(2), nan},
+		{"recall 0", m.Recall(0), 2.0 / 3},
+		{"recall 1", m.Recall(1), 1},
+		{"recall 2", m.Recall(2), 0},
+		{"F1 0", m.F1(0), 2.0 / 3},
+		{"F1 1", m.F1(1), 2.0 / 3},
+		{"F1 2", m.F1(2), 0},
+		{"macro F1", m.MacroF1(), 4.0 / 9},
+	} {
+		if !same(tc.got, tc.want) {
+			t.Errorf("%s %g, want %g", tc.name, tc.got, tc.want)
+		}
+	}
+	if got := (Matrix{{0, 0}, {0, 0}}).MacroF1(); !math.IsNaN(got) {
+		t.Errorf("macro F1 %g without samples", got)
+	}
+}
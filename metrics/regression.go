@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// RegressionReport gathers the errors of one set of predictions against their
+// targets.
+type RegressionReport struct {
+	Name     string
+	MSE, MAE float64
+	R2       float64 // coefficient of determination, NaN if the targets are constant
+	MaxError float64
+}
+
+// NewRegressionReport computes the errors of predicted against truth.
+func NewRegressionReport(name string, predicted, truth []float64) RegressionReport {
+	r := RegressionReport{Name: name}
+	mean := 0.0
+	for i := range truth {
+		mean += truth[i]
+	}
+	mean /= float64(len(truth))
+
+	total := 0.0
+	for i := range predicted {
+		e := predicted[i] - truth[i]
+		r.MSE += e * e
+		r.MAE += math.Abs(e)
+		r.MaxError = math.Max(r.MaxError, math.Abs(e))
+		total += (truth[i] - mean) * (truth[i] - mean)
+	}
+	r.R2 = math.NaN()
+	if total != 0 {
+		r.R2 = 1 - r.MSE/total
+	}
+	r.MSE /= float64(len(predicted))
+	r.MAE /= float64(len(predicted))
+	return r
+}
+
+// WriteRegressionReports prints the reports side by side, one column per
+// report.
+func WriteRegressionReports(w io.Writer, reports ...RegressionReport) {
+
+	row := func(label string, value func(r RegressionReport) float64) {
+		fmt.Fprintf(w, "%-14s", label)
+		for _, r := range reports {
+			if x := value(r); math.IsNaN(x) {
+				fmt.Fprintf(w, "%14s", "-")
+			} else {
+				fmt.Fprintf(w, "%14.6g", x)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%-14s", "")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%14s", r.Name)
+	}
+	fmt.Fprintln(w)
+	row("MSE", func(r RegressionReport) float64 { return r.MSE })
+	row("MAE", func(r RegressionReport) float64 { return r.MAE })
+	row("R2", func(r RegressionReport) float64 { return r.R2 })
+	row("max error", func(r RegressionReport) float64 { return r.MaxError })
+}
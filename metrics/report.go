@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Report gathers the metrics of one set of scores at a decision threshold.
+type Report struct {
+	Name      string
+	Threshold float64
+	Confusion
+	AUC         float64
+	Calibration float64 // NaN unless the scores are probabilities
+}
+
+// NewReport computes the metrics of scores against truth at threshold t. If
+// probabilities is set, the scores are probabilities of the positive class
+// and their calibration error over 10 bins is reported.
+func NewReport(name string, scores, truth []float64, t float64, probabilities bool) Report {
+	r := Report{
+		Name:        name,
+		Threshold:   t,
+		Confusion:   NewConfusion(Threshold(scores, t), truth),
+		AUC:         AUC(scores, truth),
+		Calibration: math.NaN(),
+	}
+	if probabilities {
+		r.Calibration = CalibrationError(scores, truth, 10)
+	}
+	return r
+}
+
+// WriteReports prints the reports side by side, one column per report.
+func WriteReports(w io.Writer, reports ...Report) {
+
+	row := func(label string, value func(r Report) string) {
+		fmt.Fprintf(w, "%-14s", label)
+		for _, r := range reports {
+			fmt.Fprintf(w, "%14s", value(r))
+		}
+		fmt.Fprintln(w)
+	}
+	number := func(x float64) string {
+		if math.IsNaN(x) {
+			return "-"
+		}
+		return fmt.Sprintf("%.4f", x)
+	}
+
+	row("", func(r Report) string { return r.Name })
+	row("threshold", func(r Report) string { return number(r.Threshold) })
+	row("TP/FP/TN/FN", func(r Report) string { return fmt.Sprintf("%d/%d/%d/%d", r.TP, r.FP, r.TN, r.FN) })
+	row("accuracy", func(r Report) string { return number(r.Accuracy()) })
+	row("precision", func(r Report) string { return number(r.Precision()) })
+	row("recall", func(r Report) string { return number(r.Recall()) })
+	row("specificity", func(r Report) string { return number(r.Specificity()) })
+	row("F1", func(r Report) string { return number(r.F1()) })
+	row("AUC", func(r Report) string { return number(r.AUC) })
+	row("ECE", func(r Report) string { return number(r.Calibration) })
+}
+
+// WriteSweep prints the threshold sweeps of several sets of scores side by
+// side. All sweeps must use the same thresholds.
+func WriteSweep(w io.Writer, names []string, sweeps ...[]SweepPoint) {
+
+	fmt.Fprintf(w, "%-10s", "threshold")
+	for _, name := range names {
+		fmt.Fprintf(w, "%28s", name+" recall/spec/F1")
+	}
+	fmt.Fprintln(w)
+
+	for i := range sweeps[0] {
+		fmt.Fprintf(w, "%-10.4f", sweeps[0][i].Threshold)
+		for _, sweep := range sweeps {
+			c := sweep[i].Confusion
+			cell := []string{fmt.Sprintf("%.3f", c.Recall()), fmt.Sprintf("%.3f", c.Specificity()), fmt.Sprintf("%.3f", c.F1())}
+			fmt.Fprintf(w, "%28s", strings.Join(cell, "/"))
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,305 @@
+// Package multiparty generates the keys of the inference among several data
+// owners, with the multiparty protocols of lattigo: the parties jointly
+// generate the public key, the relinearization key and the bootstrapping
+// keys of a collective secret key that none of them holds, and decrypting
+// takes a threshold of them. All the parties run in one process, and the
+// messages they exchange are plain function arguments.
+package multiparty
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/JohnJimAir/asimpnetwork/src"
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// Party is a data owner after the key generation: it keeps only its Shamir
+// share of the collective secret key.
+type Party struct {
+	Point mhe.ShamirPublicPoint
+	share mhe.ShamirSecretShare
+}
+
+// Keys are the collective keys the server evaluates with.
+type Keys struct {
+	Public          *rlwe.PublicKey
+	Relinearization *rlwe.RelinearizationKey
+	Bootstrapping   *bootstrapping.EvaluationKeys
+}
+
+// Session is a set of parties and their collective keys.
+type Session struct {
+	Params    hefloat.Parameters
+	Parties   []Party
+	Threshold int // number of parties a decryption takes
+	Keys
+}
+
+// BootstrappingParametersLiteral returns lit for a collective secret key of
+// the given number of parties. Without the ephemeral sparse secret, which the
+// parties cannot sample jointly, the modular reduction of the bootstrapping
+// reads inputs of the whole collective secret key, the sum of the shares of
+// the parties, each of the Hamming weight of a single key. Its interval K
+// grows with the square root of the number of parties: K = 16 already
+// overflows on some coefficients for a single key of weight 192, which spoils
+// every slot, and K = 32 for the sum of four such keys. K is the power of two
+// from 32 sqrt(parties), approximated at degree 2K - 2 up to 64; beyond, the
+// discrete cosine loses the precision of the bootstrapping, and the
+// continuous one at degree 126 takes one more double angle per doubling of K.
+// Each doubling costs a level of the bootstrapping modulus.
+func BootstrappingParametersLiteral(lit bootstrapping.ParametersLiteral, parties int) bootstrapping.ParametersLiteral {
+	K := 32
+	for float64(K) < 32*math.Sqrt(float64(parties)) {
+		K *= 2
+	}
+	lit.EphemeralSecretWeight = utils.Pointy(0)
+	lit.K = utils.Pointy(K)
+	lit.Mod1Degree = utils.Pointy(2*K - 2)
+	if K > 64 {
+		lit.Mod1Type = hefloat.CosContinuous
+		lit.Mod1Degree = utils.Pointy(126)
+		lit.DoubleAngle = utils.Pointy(3 + bits.Len(uint(K/128)))
+	}
+	return lit
+}
+
+// NewSession runs the key generation among the given number of parties: each
+// samples its share of the secret key with the Hamming weight of params. The
+// parties that keep their additive shares and collude, short of all of them,
+// thus still face the sum of the shares of the others, at least as dense as a
+// single secret key: the collective keys are as hard to break for them as
+// for anyone else. The collective secret key is denser than a single one,
+// which the interval of the bootstrapping of BootstrappingParametersLiteral
+// accounts for, and which NewSession checks. The public key and the relinearization keys come from the
+// protocols of Asharov et al. and Mouchet et al., the Galois keys of the
+// bootstrapping from those of Mouchet et al., all on the additive shares;
+// then each party deals Shamir shares of its own, which the parties
+// aggregate, so that any threshold of them can decrypt. The bootstrapping
+// parameters come from BootstrappingParametersLiteral.
+func NewSession(params hefloat.Parameters, btpParams bootstrapping.Parameters, parties, threshold int) (s *Session, err error) {
+
+	if threshold < 1 || threshold > parties {
+		return nil, fmt.Errorf("threshold of %d parties out of %d", threshold, parties)
+	}
+	if btpParams.EphemeralSecretWeight != 0 {
+		return nil, fmt.Errorf("bootstrapping with an ephemeral secret of weight %d, which the parties cannot generate", btpParams.EphemeralSecretWeight)
+	}
+	if K := BootstrappingParametersLiteral(bootstrapping.ParametersLiteral{}, parties).K; btpParams.Mod1ParametersLiteral.K < *K {
+		return nil, fmt.Errorf("bootstrapping interval K = %d for %d parties, which need %d", btpParams.Mod1ParametersLiteral.K, parties, *K)
+	}
+	weight := params.XsHammingWeight()
+
+	// Common reference string, from which the parties sample the public
+	// random polynomials of the protocols.
+	crs, err := sampling.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+
+	// Additive shares of the secret key, extended to the moduli of the
+	// bootstrapping as bootstrapping.Parameters.GenEvaluationKeys does.
+	paramsBoot := btpParams.BootstrappingParameters
+	kgen := rlwe.NewKeyGenerator(params)
+	sk := make([]*rlwe.SecretKey, parties)
+	skBoot := make([]*rlwe.SecretKey, parties)
+	for i := range sk {
+		sk[i] = kgen.GenSecretKeyWithHammingWeightNew(weight)
+		skBoot[i] = rlwe.NewSecretKey(paramsBoot)
+		buff := paramsBoot.RingQ().NewPoly()
+		rlwe.ExtendBasisSmallNormAndCenterNTTMontgomery(paramsBoot.RingQ(), paramsBoot.RingQ(), sk[i].Value.Q, buff, skBoot[i].Value.Q)
+		rlwe.ExtendBasisSmallNormAndCenterNTTMontgomery(paramsBoot.RingQ(), paramsBoot.RingP(), sk[i].Value.Q, buff, skBoot[i].Value.P)
+	}
+
+	s = &Session{Params: params, Threshold: threshold}
+	s.Public = publicKey(params, sk, crs)
+	s.Relinearization = relinearizationKey(params, sk, crs)
+
+	galois := btpParams.GaloisElements(paramsBoot)
+	gks := make([]*rlwe.GaloisKey, len(galois))
+	for k, galEl := range galois {
+		if gks[k], err = galoisKey(paramsBoot, skBoot, galEl, crs); err != nil {
+			return nil, err
+		}
+	}
+	s.Bootstrapping = &bootstrapping.EvaluationKeys{
+		MemEvaluationKeySet: rlwe.NewMemEvaluationKeySet(relinearizationKey(paramsBoot, skBoot, crs), gks...),
+	}
+
+	// Threshold sharing: party i sends the share of its secret at the point
+	// of party j to party j, which sums the shares it receives.
+	if s.Parties, err = shamirShares(params, sk, threshold); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// publicKey runs the public key generation on the additive shares.
+func publicKey(params hefloat.Parameters, sk []*rlwe.SecretKey, crs sampling.PRNG) *rlwe.PublicKey {
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	crp := ckg.SampleCRP(crs)
+	aggregate := ckg.AllocateShare()
+	share := ckg.AllocateShare()
+	for i := range sk {
+		ckg.GenShare(sk[i], crp, &share)
+		if i == 0 {
+			aggregate, share = share, aggregate
+		} else {
+			ckg.AggregateShares(aggregate, share, &aggregate)
+		}
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(aggregate, crp, pk)
+	return pk
+}
+
+// relinearizationKey runs the two rounds of the relinearization key
+// generation on the additive shares.
+func relinearizationKey(params hefloat.Parameters, sk []*rlwe.SecretKey, crs sampling.PRNG) *rlwe.RelinearizationKey {
+	rkg := mhe.NewRelinearizationKeyGenProtocol(params)
+	crp := rkg.SampleCRP(crs)
+	ephemeral := make([]*rlwe.SecretKey, len(sk))
+	round1 := make([]mhe.RelinearizationKeyGenShare, len(sk))
+	round2 := make([]mhe.RelinearizationKeyGenShare, len(sk))
+	for i := range sk {
+		ephemeral[i], round1[i], round2[i] = rkg.AllocateShare()
+	}
+
+	// The shares of each round are aggregated into those of the first party.
+	for i := range sk {
+		rkg.GenShareRoundOne(sk[i], crp, ephemeral[i], &round1[i])
+		if i > 0 {
+			rkg.AggregateShares(round1[0], round1[i], &round1[0])
+		}
+	}
+	for i := range sk {
+		rkg.GenShareRoundTwo(ephemeral[i], sk[i], round1[0], &round2[i])
+		if i > 0 {
+			rkg.AggregateShares(round2[0], round2[i], &round2[0])
+		}
+	}
+	rlk := rlwe.NewRelinearizationKey(params)
+	rkg.GenRelinearizationKey(round1[0], round2[0], rlk)
+	return rlk
+}
+
+// galoisKey runs the Galois key generation of galEl on the additive shares.
+func galoisKey(params hefloat.Parameters, sk []*rlwe.SecretKey, galEl uint64, crs sampling.PRNG) (gk *rlwe.GaloisKey, err error) {
+	gkg := mhe.NewGaloisKeyGenProtocol(params)
+	crp := gkg.SampleCRP(crs)
+	aggregate := gkg.AllocateShare()
+	share := gkg.AllocateShare()
+	for i := range sk {
+		if err = gkg.GenShare(sk[i], galEl, crp, &share); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			aggregate, share = share, aggregate
+		} else if err = gkg.AggregateShares(aggregate, share, &aggregate); err != nil {
+			return nil, err
+		}
+	}
+	gk = rlwe.NewGaloisKey(params)
+	if err = gkg.GenGaloisKey(aggregate, crp, gk); err != nil {
+		return nil, err
+	}
+	return gk, nil
+}
+
+// shamirShares returns the parties, at the points 1, 2, ..., with their
+// aggregated Shamir share of the sum of the additive shares.
+func shamirShares(params hefloat.Parameters, sk []*rlwe.SecretKey, threshold int) (parties []Party, err error) {
+	thr := mhe.NewThresholdizer(params)
+	parties = make([]Party, len(sk))
+	for j := range parties {
+		parties[j] = Party{Point: mhe.ShamirPublicPoint(j + 1), share: thr.AllocateThresholdSecretShare()}
+	}
+	share := thr.AllocateThresholdSecretShare()
+	for i := range sk {
+		polynomial, err := thr.GenShamirPolynomial(threshold, sk[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := range parties {
+			thr.GenShamirSecretShare(parties[j].Point, polynomial, &share)
+			if err = thr.AggregateShares(parties[j].share, share, &parties[j].share); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return parties, nil
+}
+
+// Decrypt returns the plaintext of ct, decrypted by the parties of the given
+// indices, of which the first Threshold take part. Each turns its Shamir
+// share into an additive share of the collective secret key among them, and
+// switches ct to the zero key with it in the protocol of Mouchet et al., with
+// the noise of a fresh encryption; then it adds to its decryption share, so
+// that it does not reveal that share, noise of src.FloodingPoly of deviation
+// sigma over the square root of Threshold, for a deviation of sigma on the
+// decoded value of each slot in all, as src.Flood. A sigma of 0 thus only
+// smudges with fresh noise. The parties must be distinct, and sigma pass
+// src.CheckFlooding on ct.
+func (s *Session) Decrypt(ct *rlwe.Ciphertext, active []int, sigma float64) (pt *rlwe.Plaintext, err error) {
+
+	if len(active) < s.Threshold {
+		return nil, fmt.Errorf("decryption by %d parties, the threshold is %d", len(active), s.Threshold)
+	}
+	active = active[:s.Threshold]
+	points := make([]mhe.ShamirPublicPoint, len(active))
+	seen := make(map[int]bool, len(active))
+	for k, i := range active {
+		if i < 0 || i >= len(s.Parties) {
+			return nil, fmt.Errorf("party %d of %d", i, len(s.Parties))
+		}
+		if seen[i] {
+			return nil, fmt.Errorf("party %d takes part twice", i)
+		}
+		seen[i] = true
+		points[k] = s.Parties[i].Point
+	}
+	if err = src.CheckFlooding(ct, sigma, s.Params); err != nil {
+		return nil, err
+	}
+
+	cks, err := mhe.NewKeySwitchProtocol(s.Params, ring.DiscreteGaussian{Sigma: 0, Bound: 0})
+	if err != nil {
+		return nil, err
+	}
+
+	ringQ := s.Params.RingQ().AtLevel(ct.Level())
+	zero := rlwe.NewSecretKey(s.Params)
+	aggregate := cks.AllocateShare(ct.Level())
+	share := cks.AllocateShare(ct.Level())
+	for k, i := range active {
+		cmb := mhe.NewCombiner(*s.Params.GetRLWEParameters(), points[k], points, s.Threshold)
+		additive := rlwe.NewSecretKey(s.Params)
+		if err = cmb.GenAdditiveShare(points, points[k], s.Parties[i].share, additive); err != nil {
+			return nil, err
+		}
+		cks.GenShare(additive, zero, ct, &share)
+		if sigma > 0 {
+			e, err := src.FloodingPoly(ct, sigma/math.Sqrt(float64(s.Threshold)), s.Params)
+			if err != nil {
+				return nil, err
+			}
+			ringQ.Add(share.Value, e, share.Value)
+		}
+		if k == 0 {
+			aggregate, share = share, aggregate
+		} else if err = cks.AggregateShares(aggregate, share, &aggregate); err != nil {
+			return nil, err
+		}
+	}
+
+	switched := rlwe.NewCiphertext(s.Params, ct.Degree(), ct.Level())
+	cks.KeySwitch(ct, aggregate, switched)
+	return rlwe.NewDecryptor(s.Params, zero).DecryptNew(switched), nil
+}
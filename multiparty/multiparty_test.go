@@ -0,0 +1,218 @@
+package multiparty
+
+import (
+	"math"
+	"testing"
+
+	"github.com/JohnJimAir/asimpnetwork/src"
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils"
+)
+
+// newSession returns a session of the given parties and threshold, on the
+// parameters of the examples at the insecure ring degree of -short, as
+// cmd/infer -short builds them.
+func newSession(t *testing.T, parties, threshold int) (*Session, bootstrapping.Parameters) {
+
+	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+		LogN:            13,
+		LogQ:            []int{55, 40, 40, 40, 40, 40, 40, 40, 40, 40, 40},
+		LogP:            []int{61, 61, 61},
+		LogDefaultScale: 40,
+		Xs:              ring.Ternary{H: 192},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	btpParams, err := bootstrapping.NewParametersFromLiteral(params, BootstrappingParametersLiteral(bootstrapping.ParametersLiteral{
+		LogN: utils.Pointy(13),
+		LogP: []int{61, 61, 61, 61},
+		Xs:   params.Xs(),
+	}, parties))
+	if err != nil {
+		t.Fatal(err)
+	}
+	btpParams.Mod1ParametersLiteral.LogMessageRatio += 16 - params.LogN()
+	s, err := NewSession(params, btpParams, parties, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, btpParams
+}
+
+func TestDecrypt(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("key generation of the bootstrapping")
+	}
+	s, _ := newSession(t, 3, 2)
+
+	values := make([]float64, s.Params.MaxSlots())
+	for i := range values {
+		values[i] = math.Sin(float64(i))
+	}
+	pt := hefloat.NewPlaintext(s.Params, s.Params.MaxLevel())
+	if err := hefloat.NewEncoder(s.Params).Encode(values, pt); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := rlwe.NewEncryptor(s.Params, s.Public).EncryptNew(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := ct.CopyNew()
+	hefloat.NewEvaluator(s.Params, nil).DropLevel(last, last.Level())
+
+	// At the last level, the modulus of 55 bits leaves room for a flooding
+	// deviation of 2^14 / 6 at the scale of 2^40, as for src.Flood.
+	for _, tc := range []struct {
+		name   string
+		active []int
+		ct     *rlwe.Ciphertext
+		sigma  float64
+		want   float64 // largest error of a slot, 0 if the decryption fails
+	}{
+		{"first", []int{0, 1}, ct, 0, 1e-6},
+		{"last", []int{2, 1}, ct, 0, 1e-6},
+		{"more than the threshold", []int{1, 2, 0}, ct, 0, 1e-6},
+		{"smudged", []int{0, 2}, ct, 1e-3, 1e-2},
+		{"smudged beyond a prime", []int{0, 1}, ct, 1e3, 1e4},
+		{"smudged at the last level", []int{1, 2}, last, 100, 1e3},
+		{"below the threshold", []int{0}, ct, 0, 0},
+		{"twice the same party", []int{1, 1}, ct, 0, 0},
+		{"unknown party", []int{0, 3}, ct, 0, 0},
+		{"above the flooding bound", []int{0, 1}, ct, 1e130, 0},
+		{"above the flooding bound at the last level", []int{0, 1}, last, 1e4, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.want == 0 {
+				if err := src.CheckFlooding(tc.ct, tc.sigma, s.Params); tc.sigma > 0 && err == nil {
+					t.Fatal("within the flooding bound")
+				}
+			}
+			pt, err := s.Decrypt(tc.ct, tc.active, tc.sigma)
+			if tc.want == 0 {
+				if err == nil {
+					t.Fatal("decrypted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]float64, len(values))
+			if err = hefloat.NewEncoder(s.Params).Decode(pt, got); err != nil {
+				t.Fatal(err)
+			}
+			for i := range values {
+				if e := math.Abs(got[i] - values[i]); !(e < tc.want) {
+					t.Fatalf("slot %d: error %g", i, e)
+				}
+			}
+		})
+	}
+}
+
+// TestEvaluate runs a multiplication, its relinearization and rescaling, and
+// a bootstrapping with the collective evaluation keys, as the server does on
+// the layers of a model, and decrypts the result with a threshold of the
+// parties.
+func TestEvaluate(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("key generation of the bootstrapping")
+	}
+	for _, tc := range []struct{ parties, threshold int }{{3, 2}, {3, 3}, {2, 1}, {5, 3}} {
+		s, btpParams := newSession(t, tc.parties, tc.threshold)
+		eval := hefloat.NewEvaluator(s.Params, rlwe.NewMemEvaluationKeySet(s.Relinearization))
+		eval_boot, err := bootstrapping.NewEvaluator(btpParams, s.Bootstrapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoder := hefloat.NewEncoder(s.Params)
+
+		values := make([]float64, s.Params.MaxSlots())
+		for i := range values {
+			values[i] = math.Sin(float64(i))
+		}
+		pt := hefloat.NewPlaintext(s.Params, s.Params.MaxLevel())
+		if err = encoder.Encode(values, pt); err != nil {
+			t.Fatal(err)
+		}
+		ct, err := rlwe.NewEncryptor(s.Params, s.Public).EncryptNew(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ct, err = eval.MulRelinNew(ct, ct); err != nil {
+			t.Fatal(err)
+		}
+		if err = eval.Rescale(ct, ct); err != nil {
+			t.Fatal(err)
+		}
+		if ct, err = eval_boot.Bootstrap(ct); err != nil {
+			t.Fatal(err)
+		}
+		if ct.Level() != s.Params.MaxLevel() {
+			t.Errorf("bootstrapped to level %d", ct.Level())
+		}
+
+		active := make([]int, tc.threshold)
+		for k := range active {
+			active[k] = tc.parties - 1 - k
+		}
+		if pt, err = s.Decrypt(ct, active, 0); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]float64, len(values))
+		if err = encoder.Decode(pt, got); err != nil {
+			t.Fatal(err)
+		}
+		worst := 0.0
+		for i := range values {
+			worst = math.Max(worst, math.Abs(got[i]-values[i]*values[i]))
+		}
+		t.Logf("%d parties, threshold %d: largest error %.3e", tc.parties, tc.threshold, worst)
+		if !(worst < 1e-4) {
+			t.Errorf("%d parties, threshold %d: largest error %g", tc.parties, tc.threshold, worst)
+		}
+	}
+}
+
+func TestNewSessionChecks(t *testing.T) {
+
+	params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+		LogN:            13,
+		LogQ:            []int{55, 40, 40, 40, 40, 40, 40, 40, 40, 40, 40},
+		LogP:            []int{61, 61, 61},
+		LogDefaultScale: 40,
+		Xs:              ring.Ternary{H: 192},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lit := bootstrapping.ParametersLiteral{LogN: utils.Pointy(13), LogP: []int{61, 61, 61, 61}, Xs: params.Xs()}
+	for _, tc := range []struct {
+		name               string
+		lit                bootstrapping.ParametersLiteral
+		parties, threshold int
+	}{
+		{"zero threshold", BootstrappingParametersLiteral(lit, 3), 3, 0},
+		{"threshold above the parties", BootstrappingParametersLiteral(lit, 3), 3, 4},
+		{"ephemeral secret", lit, 3, 2},
+		{"interval of fewer parties", BootstrappingParametersLiteral(lit, 2), 5, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			btpParams, err := bootstrapping.NewParametersFromLiteral(params, tc.lit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = NewSession(params, btpParams, tc.parties, tc.threshold); err == nil {
+				t.Fatal("session created")
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+)
+
+// Argmax returns, per slot, the encrypted one-hot vector of the largest of
+// the outputs of a multi-class model, by a round-robin tournament: every pair
+// i < j is compared once, s_ij being the step at 0 of output[j] - output[i]
+// on its range from OutputIntervals, and the class i wins all its games with
+//
+//	onehot[i] = prod_(j < i) s_ji * prod_(j > i) (1 - s_ij).
+//
+// The N(N-1)/2 comparisons run side by side, so that the depth is that of a
+// single one, as in Decide, and of a product of N - 1 factors; products
+// without levels left are bootstrapped. Outputs within a fraction of a
+// percent of the range of their difference from each other share a soft win,
+// which the client rounds.
+func (m Model) Argmax(output []*rlwe.Ciphertext, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (onehot []*rlwe.Ciphertext) {
+
+	if m.IsRegression() {
+		panic(fmt.Errorf("argmax on the predictions of a regression model"))
+	}
+	intervals, err := m.OutputIntervals()
+	if err != nil {
+		panic(err)
+	}
+	num := len(output)
+	if num < 2 {
+		panic(fmt.Errorf("argmax of %d outputs", num))
+	}
+	reserve := bits.Len(uint(num - 2))
+
+	factors := make([][]*rlwe.Ciphertext, num)
+	for i := 0; i < num; i++ {
+		for j := i + 1; j < num; j++ {
+			interval := []float64{intervals[j][0] - intervals[i][1], intervals[j][1] - intervals[i][0]}
+			if math.IsInf(interval[0], 0) || math.IsInf(interval[1], 0) || !(interval[0] < 0 && interval[1] > 0) {
+				panic(fmt.Errorf("comparison of outputs %d and %d on the range %v", i, j, interval))
+			}
+			difference := Innerproduct([]float64{-1, 1}, 0, []*rlwe.Ciphertext{output[i], output[j]}, eval)
+			win := compare(difference, interval, 0, reserve, eval, eval_boot, params)
+
+			loss, err := eval.MulNew(win, -1)
+			if err != nil {
+				panic(err)
+			}
+			if err = eval.Add(loss, 1, loss); err != nil {
+				panic(err)
+			}
+			factors[i] = append(factors[i], loss)
+			factors[j] = append(factors[j], win)
+		}
+	}
+
+	onehot = make([]*rlwe.Ciphertext, num)
+	for i := range factors {
+		onehot[i] = product(factors[i], eval, eval_boot)
+	}
+	return onehot
+}
+
+// product returns the product of the factors by a balanced tree,
+// bootstrapping those left without a level.
+func product(factors []*rlwe.Ciphertext, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator) *rlwe.Ciphertext {
+
+	for len(factors) > 1 {
+		var next []*rlwe.Ciphertext
+		for k := 0; k+1 < len(factors); k += 2 {
+			a, b := factors[k], factors[k+1]
+			if a.Level() == 0 {
+				a = Bootstrap(eval_boot, []*rlwe.Ciphertext{a})[0]
+			}
+			if b.Level() == 0 {
+				b = Bootstrap(eval_boot, []*rlwe.Ciphertext{b})[0]
+			}
+			c, err := eval.MulRelinNew(a, b)
+			if err != nil {
+				panic(err)
+			}
+			if err = eval.Rescale(c, c); err != nil {
+				panic(err)
+			}
+			next = append(next, c)
+		}
+		if len(factors)%2 == 1 {
+			next = append(next, factors[len(factors)-1])
+		}
+		factors = next
+	}
+	return factors[0]
+}
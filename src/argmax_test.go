@@ -0,0 +1,42 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArgmax(t *testing.T) {
+
+	k := newTestKeys(t)
+	outputs := [][]float64{
+		{0.9, 0.1, 0.3, -1, 0.5, 0.2},
+		{0.2, 0.8, 0.4, 1, -0.5, 0.3},
+		{0.1, 0.3, 0.7, 0.2, 0, 0.9},
+	}
+	m := passThrough([][]float64{{-1, 1}, {-1, 1}, {-1, 1}})
+	onehot := m.Argmax(k.encrypt(t, outputs), k.eval, k.eval_boot, k.params)
+	if len(onehot) != len(outputs) {
+		t.Fatalf("%d one-hot entries for %d outputs", len(onehot), len(outputs))
+	}
+	got := make([][]float64, len(onehot))
+	for j := range onehot {
+		got[j] = k.decrypt(t, onehot[j], len(outputs[0]))
+	}
+	for i := range outputs[0] {
+		best := 0
+		for j := range outputs {
+			if outputs[j][i] > outputs[best][i] {
+				best = j
+			}
+		}
+		for j := range outputs {
+			want := 0.0
+			if j == best {
+				want = 1
+			}
+			if math.Abs(got[j][i]-want) > 0.01 {
+				t.Errorf("slot %d: entry %d is %g, want %g", i, j, got[j][i], want)
+			}
+		}
+	}
+}
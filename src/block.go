@@ -3,11 +3,14 @@ package src
 import (
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
 	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
 )
 
 type Block struct {
 	Num_node int
 	Nodes []Node
+	Reserve int // levels the strategies leave on their outputs, for the next blocks
+	Boot *bootstrapping.Evaluator // refreshes the clamps of the nodes, if any
 }
 
 func (bl *Block) Initialize(num_node int, coefficients_mult [][]float64, coefficient_add []float64, activation []func (float64) (float64), input [][]*rlwe.Ciphertext) {
@@ -24,11 +27,20 @@ func (bl *Block) Initialize(num_node int, coefficients_mult [][]float64, coeffic
 	}
 }
 
+// Forward evaluates the nodes of the block with their strategies, which share
+// an Evaluation: nodes of the polynomial strategy whose inner products fused
+// with the change of basis are identical, that is with the same inputs,
+// weights, constant and interval up to the affine map, share their Chebyshev
+// power basis. Each node may consume the levels left on its inputs beyond
+// bl.Reserve, clamps included; the clamps are bootstrapped with bl.Boot.
 func (bl Block) Forward(intervals [][]float64, degrees []int, eval *hefloat.Evaluator, params hefloat.Parameters) (output []*rlwe.Ciphertext) {
 
+	ev := NewEvaluation(eval, params)
+	ev.Boot = bl.Boot
 	output = make([]*rlwe.Ciphertext, bl.Num_node)
 	for i:=0;i<bl.Num_node;i++ {
-		output[i] = bl.Nodes[i].Forward(intervals[i], degrees[i], eval, params)
+		n := bl.Nodes[i]
+		output[i], _ = n.evaluate(intervals[i], degrees[i], n.Input[0].Level()-bl.Reserve, ev)
 	}
 	return output
-}
\ No newline at end of file
+}
@@ -0,0 +1,219 @@
+package src
+
+import (
+	"fmt"
+	"math"
+)
+
+// Image returns an enclosure of the values of the named activation on
+// [lo, hi]. The bounds are infinite where the activation is unbounded, as tan
+// around a pole, or log near 0. Inputs outside the domain of log, sqrt,
+// invsqrt and inverse are reported as an error.
+func Image(activation string, lo, hi float64) (image []float64, err error) {
+
+	switch activation {
+	case "identity":
+		return []float64{lo, hi}, nil
+	case "tanh":
+		return []float64{math.Tanh(lo), math.Tanh(hi)}, nil
+	case "exp":
+		return []float64{math.Exp(lo), math.Exp(hi)}, nil
+	case "pow3":
+		return []float64{lo * lo * lo, hi * hi * hi}, nil
+	case "abs", "pow2":
+		a, b := math.Abs(lo), math.Abs(hi)
+		low, high := math.Min(a, b), math.Max(a, b)
+		if lo <= 0 && hi >= 0 {
+			low = 0
+		}
+		if activation == "pow2" {
+			return []float64{low * low, high * high}, nil
+		}
+		return []float64{low, high}, nil
+	case "sqrt":
+		if lo < 0 {
+			err = fmt.Errorf("sqrt of [%g, %g]", lo, hi)
+		}
+		return []float64{math.Sqrt(math.Max(lo, 0)), math.Sqrt(math.Max(hi, 0))}, err
+	case "invsqrt":
+		if lo <= 0 {
+			err = fmt.Errorf("invsqrt of [%g, %g]", lo, hi)
+			return []float64{1 / math.Sqrt(math.Max(hi, 0)), math.Inf(1)}, err
+		}
+		return []float64{1 / math.Sqrt(hi), 1 / math.Sqrt(lo)}, nil
+	case "inverse":
+		if lo <= 0 && hi >= 0 {
+			return []float64{math.Inf(-1), math.Inf(1)}, fmt.Errorf("inverse of [%g, %g]", lo, hi)
+		}
+		return []float64{1 / hi, 1 / lo}, nil
+	case "log":
+		if lo <= 0 {
+			err = fmt.Errorf("log of [%g, %g]", lo, hi)
+		}
+		return []float64{math.Log(math.Max(lo, 0)), math.Log(math.Max(hi, 0))}, err
+	case "sin":
+		if hi-lo >= 2*math.Pi || math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+			return []float64{-1, 1}, nil
+		}
+		low, high := math.Min(math.Sin(lo), math.Sin(hi)), math.Max(math.Sin(lo), math.Sin(hi))
+		// extrema at pi/2 + 2k pi (max) and -pi/2 + 2k pi (min)
+		if math.Ceil((lo-math.Pi/2)/(2*math.Pi)) <= math.Floor((hi-math.Pi/2)/(2*math.Pi)) {
+			high = 1
+		}
+		if math.Ceil((lo+math.Pi/2)/(2*math.Pi)) <= math.Floor((hi+math.Pi/2)/(2*math.Pi)) {
+			low = -1
+		}
+		return []float64{low, high}, nil
+	case "tan":
+		// poles at pi/2 + k pi
+		if hi-lo >= math.Pi || math.Ceil((lo-math.Pi/2)/math.Pi) <= math.Floor((hi-math.Pi/2)/math.Pi) {
+			return []float64{math.Inf(-1), math.Inf(1)}, nil
+		}
+		return []float64{math.Tan(lo), math.Tan(hi)}, nil
+	}
+	return nil, fmt.Errorf("no interval extension for activation %q", activation)
+}
+
+// Image returns an enclosure of the outputs of the node for inputs in [lo, hi],
+// through its activation maps.
+func (ns NodeSpec) Image(lo, hi float64) (image []float64, err error) {
+	a, b, c, d := ns.Maps()
+	lo, hi = math.Min(a*lo+b, a*hi+b), math.Max(a*lo+b, a*hi+b)
+	image, err = Image(ns.Activation, lo, hi)
+	if image != nil {
+		image = []float64{math.Min(c*image[0]+d, c*image[1]+d), math.Max(c*image[0]+d, c*image[1]+d)}
+	}
+	return image, err
+}
+
+// Bounds holds an interval per node of a model: Pre for the input of the
+// activations, Post for their output.
+type Bounds struct {
+	Pre  [][][]float64
+	Post [][][]float64
+}
+
+// PropagateBounds computes guaranteed bounds on every node of the model, given
+// a range per feature. Edges of weight 0 do not contribute, so that an
+// unbounded dead node does not spoil the bounds of its consumers. Errors
+// report nodes whose input may leave the domain of their activation.
+func (m Model) PropagateBounds(ranges [][]float64) (b Bounds, errs []error) {
+
+	b.Pre = make([][][]float64, len(m.Layers))
+	b.Post = make([][][]float64, len(m.Layers))
+
+	x := ranges
+	for l, layer := range m.Layers {
+		b.Pre[l] = make([][]float64, len(layer.Nodes))
+		b.Post[l] = make([][]float64, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			lo, hi := ns.Coefficient_add, ns.Coefficient_add
+			for k, j := range ns.Input {
+				w := ns.Coefficients_mult[k]
+				if w == 0 {
+					continue
+				}
+				lo += math.Min(w*x[j][0], w*x[j][1])
+				hi += math.Max(w*x[j][0], w*x[j][1])
+			}
+			b.Pre[l][i] = []float64{lo, hi}
+
+			var err error
+			if b.Post[l][i], err = ns.Image(lo, hi); err != nil {
+				errs = append(errs, fmt.Errorf("layer %d node %d: %w", l, i, err))
+			}
+		}
+		x = b.Post[l]
+	}
+	return b, errs
+}
+
+// EmpiricalBounds returns the smallest intervals containing the values of every
+// node on the samples, given as rows in the order of m.Features.
+func (m Model) EmpiricalBounds(rows [][]float64) (b Bounds) {
+
+	b.Pre = make([][][]float64, len(m.Layers))
+	b.Post = make([][][]float64, len(m.Layers))
+	for l, layer := range m.Layers {
+		b.Pre[l] = make([][]float64, len(layer.Nodes))
+		b.Post[l] = make([][]float64, len(layer.Nodes))
+		for i := range layer.Nodes {
+			b.Pre[l][i] = []float64{math.Inf(1), math.Inf(-1)}
+			b.Post[l][i] = []float64{math.Inf(1), math.Inf(-1)}
+		}
+	}
+
+	for _, x := range rows {
+		pre := m.Preactivations(x)
+		for l, layer := range m.Layers {
+			for i, ns := range layer.Nodes {
+				y := ns.Function()(pre[l][i])
+				b.Pre[l][i][0] = math.Min(b.Pre[l][i][0], pre[l][i])
+				b.Pre[l][i][1] = math.Max(b.Pre[l][i][1], pre[l][i])
+				b.Post[l][i][0] = math.Min(b.Post[l][i][0], y)
+				b.Post[l][i][1] = math.Max(b.Post[l][i][1], y)
+			}
+		}
+	}
+	return b
+}
+
+// WithIntervals returns a copy of m whose node intervals are the given
+// pre-activation bounds, widened by margin times their width on each side,
+// except where the activation is undefined, as log or sqrt below 0. Nodes with
+// an unbounded or empty bound keep their interval.
+func (m Model) WithIntervals(pre [][][]float64, margin float64) Model {
+	m = m.Clone()
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			lo, hi := pre[l][i][0], pre[l][i][1]
+			if math.IsInf(lo, 0) || math.IsInf(hi, 0) || math.IsNaN(lo) || math.IsNaN(hi) || lo > hi {
+				continue
+			}
+			pad := margin * (hi - lo)
+			if pad == 0 {
+				pad = margin
+			}
+			f := m.Layers[l].Nodes[i].Function()
+			if defined := f(lo - pad); !math.IsNaN(defined) && !math.IsInf(defined, 0) {
+				lo -= pad
+			}
+			if defined := f(hi + pad); !math.IsNaN(defined) && !math.IsInf(defined, 0) {
+				hi += pad
+			}
+			m.Layers[l].Nodes[i].Interval = []float64{lo, hi}
+		}
+	}
+	return m
+}
+
+// DeclaredBounds propagates bounds through the model from the declared ranges
+// of the features, or from their FeatureIntervals if it declares none.
+func (m Model) DeclaredBounds() (b Bounds, errs []error) {
+	ranges := m.Ranges
+	if ranges == nil {
+		ranges = m.FeatureIntervals()
+	}
+	return m.PropagateBounds(ranges)
+}
+
+// Reach returns, for each node, the half-width of the range its clamp is
+// bounded on, in half-widths of its interval and around its center: ClampRange,
+// or farther where the inner product of the node does in DeclaredBounds, and
+// +Inf where that is unbounded.
+func (m Model) Reach() (reach [][]float64) {
+	b, _ := m.DeclaredBounds()
+	reach = make([][]float64, len(m.Layers))
+	for l, layer := range m.Layers {
+		reach[l] = make([]float64, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			center, half := (ns.Interval[0]+ns.Interval[1])/2, (ns.Interval[1]-ns.Interval[0])/2
+			lo, hi := b.Pre[l][i][0], b.Pre[l][i][1]
+			reach[l][i] = math.Max(ClampRange, math.Max(math.Abs(lo-center), math.Abs(hi-center))/half)
+			if math.IsNaN(lo) || math.IsNaN(hi) {
+				reach[l][i] = math.Inf(1)
+			}
+		}
+	}
+	return reach
+}
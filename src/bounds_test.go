@@ -0,0 +1,123 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+)
+
+// encloses reports whether y lies in image, up to rounding.
+func encloses(image []float64, y float64) bool {
+	tol := 1e-12 * math.Max(1, math.Abs(y))
+	return y >= image[0]-tol && y <= image[1]+tol
+}
+
+func TestImage(t *testing.T) {
+
+	for _, tc := range []struct {
+		activation string
+		lo, hi     float64
+		fails      bool
+	}{
+		{"identity", -3, 2, false},
+		{"tanh", -4, 0.5, false},
+		{"exp", -2, 3, false},
+		{"pow3", -2, 1.5, false},
+		{"abs", -3, 2, false},
+		{"abs", 1, 4, false},
+		{"abs", -4, -1, false},
+		{"pow2", -1, 3, false},
+		{"pow2", -3, -2, false},
+		{"sqrt", 0.5, 9, false},
+		{"sqrt", -1, 4, true},
+		{"invsqrt", 0.25, 4, false},
+		{"invsqrt", 0, 4, true},
+		{"inverse", 0.5, 2, false},
+		{"inverse", -2, -0.5, false},
+		{"inverse", -1, 1, true},
+		{"log", 0.1, 10, false},
+		{"log", 0, 10, true},
+		{"sin", 0, 1, false},
+		{"sin", 1, 2, false},
+		{"sin", 4, 5, false},
+		{"sin", -7.5, -6, false},
+		{"sin", 0, 7, false},
+		{"tan", -1.5, 1.5, false},
+		{"tan", 1.6, 4.6, false},
+		{"tan", 1, 2, false},
+	} {
+		image, err := Image(tc.activation, tc.lo, tc.hi)
+		if (err != nil) != tc.fails {
+			t.Errorf("%s on [%g, %g]: error %v", tc.activation, tc.lo, tc.hi, err)
+		}
+		if tc.fails {
+			continue
+		}
+		if len(image) != 2 || !(image[0] <= image[1]) {
+			t.Errorf("%s on [%g, %g]: image %v", tc.activation, tc.lo, tc.hi, image)
+			continue
+		}
+		f := Activations[tc.activation]
+		for k := 0; k <= 4096; k++ {
+			x := tc.lo + (tc.hi-tc.lo)*float64(k)/4096
+			if y := f(x); !encloses(image, y) {
+				t.Errorf("%s on [%g, %g]: %s(%g) = %g outside %v", tc.activation, tc.lo, tc.hi, tc.activation, x, y, image)
+				break
+			}
+		}
+	}
+
+	if _, err := Image("softplus", 0, 1); err == nil {
+		t.Error("image of an unknown activation")
+	}
+}
+
+func TestNodeImage(t *testing.T) {
+
+	for _, ns := range []NodeSpec{
+		{Activation: "tanh", Activation_in: []float64{-2, 1}, Activation_out: []float64{3, -1}},
+		{Activation: "sin", Activation_in: []float64{0.5, -4}, Activation_out: []float64{-0.2, 0.7}},
+		{Activation: "pow2", Activation_out: []float64{-1, 0}},
+	} {
+		lo, hi := -2.5, 3.0
+		image, err := ns.Image(lo, hi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := ns.Function()
+		for k := 0; k <= 4096; k++ {
+			x := lo + (hi-lo)*float64(k)/4096
+			if y := f(x); !encloses(image, y) {
+				t.Errorf("%s node: f(%g) = %g outside %v", ns.Activation, x, y, image)
+				break
+			}
+		}
+	}
+}
+
+func TestPropagateBounds(t *testing.T) {
+
+	m := BreastCancerModel()
+	data, err := dataset.Load("../data/test_data_breast-cancer.csv", dataset.Schema{Features: m.Features})
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared, errs := m.DeclaredBounds()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	empirical := m.EmpiricalBounds(data.Rows)
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			for _, b := range [][2][]float64{
+				{declared.Pre[l][i], empirical.Pre[l][i]},
+				{declared.Post[l][i], empirical.Post[l][i]},
+			} {
+				if !encloses(b[0], b[1][0]) || !encloses(b[0], b[1][1]) {
+					t.Errorf("layer %d node %d: declared %v does not enclose empirical %v", l, i, b[0], b[1])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package src
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+)
+
+// Noise is a rough model of the CKKS errors, as absolute errors on the
+// messages.
+type Noise struct {
+	Fresh     float64 // error of a fresh encryption
+	Op        float64 // error added by a rescaled multiplication
+	Bootstrap float64 // error added by a bootstrapping
+}
+
+// NewNoise estimates the CKKS errors of params: the encryption error is about
+// 6 sigma sqrt(N), the rounding error of a rescale about sqrt(N), both over the
+// default scale. The bootstrapping error depends on its own parameters and is
+// given.
+func NewNoise(params hefloat.Parameters, bootstrap float64) Noise {
+	scale := params.DefaultScale().Float64()
+	n := math.Sqrt(float64(params.N()))
+	return Noise{
+		Fresh:     6 * params.NoiseFreshSK() * n / scale,
+		Op:        n / scale,
+		Bootstrap: bootstrap,
+	}
+}
+
+// Budget estimates how the error introduced at each node reaches the model
+// outputs. Errors are bounds to first order: an error e on the input of a
+// node leaves it as Lipschitz * e, and reaches a consumer as |weight| * e.
+type Budget struct {
+	Approximation [][]float64   // max |f - p| on the interval of each node
+	Noise         [][]float64   // CKKS error introduced by each node, at its output
+	Lipschitz     [][]float64   // max |p'| on the interval of each node
+	Gain          [][][]float64 // Gain[l][i][j] amplifies an error on the output of node i of layer l into output j
+	Inputs        []float64     // error of each output due to the encryption of the features
+	Outputs       []float64     // error bound of each output
+}
+
+// Contribution is the share of one node in the error of the outputs.
+type Contribution struct {
+	Layer, Node   int
+	Approximation float64 // approximation error of the node, on the outputs
+	Noise         float64 // CKKS error of the node, on the outputs
+}
+
+// Total returns the error the node adds to the outputs.
+func (c Contribution) Total() float64 {
+	return c.Approximation + c.Noise
+}
+
+// ErrorBudget estimates the error budget of the model, evaluated with its
+// intervals and degrees under the noise model, on inputs of the activations
+// within pre, as the bounds of PropagateBounds or EmpiricalBounds: the errors
+// and Lipschitz factors of the polynomials are those on these bounds, where
+// the polynomials are evaluated, rather than on the whole intervals. A nil pre,
+// or an unbounded or empty bound, stands for the interval of the node. Dead
+// nodes contribute nothing.
+func (m Model) ErrorBudget(noise Noise, pre [][][]float64) (b Budget) {
+
+	num := len(m.Layers)
+	if num == 0 {
+		return b
+	}
+	b.Approximation = make([][]float64, num)
+	b.Noise = make([][]float64, num)
+	b.Lipschitz = make([][]float64, num)
+	b.Gain = make([][][]float64, num)
+
+	for l, layer := range m.Layers {
+		b.Approximation[l] = make([]float64, len(layer.Nodes))
+		b.Noise[l] = make([]float64, len(layer.Nodes))
+		b.Lipschitz[l] = make([]float64, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			lo, hi := ns.Interval[0], ns.Interval[1]
+			if pre != nil && bounded(pre[l][i]) {
+				lo, hi = pre[l][i][0], pre[l][i][1]
+			}
+			approx, lipschitz, norm := ns.approximationError(lo, hi, 512)
+			b.Approximation[l][i] = approx
+			b.Lipschitz[l][i] = lipschitz
+
+			// The inner product fused with the change of basis rescales once,
+			// then every level of the polynomial evaluation rescales terms of
+			// weight up to its coefficients.
+			levels := math.Ceil(math.Log2(float64(ns.Degree + 1)))
+			b.Noise[l][i] = noise.Op*lipschitz + levels*norm*noise.Op
+			if layer.Bootstrap {
+				b.Noise[l][i] += noise.Bootstrap
+			}
+		}
+	}
+
+	// Gains, from the outputs backwards.
+	dead := m.Dead()
+	outputs := len(m.Layers[num-1].Nodes)
+	for l := num - 1; l >= 0; l-- {
+		b.Gain[l] = make([][]float64, len(m.Layers[l].Nodes))
+		for i := range b.Gain[l] {
+			b.Gain[l][i] = make([]float64, outputs)
+			if l == num-1 {
+				b.Gain[l][i][i] = 1
+			}
+		}
+		if l == num-1 {
+			continue
+		}
+		for n, ns := range m.Layers[l+1].Nodes {
+			if dead[l+1][n] {
+				continue
+			}
+			for k, i := range ns.Input {
+				for j := range b.Gain[l][i] {
+					b.Gain[l][i][j] += math.Abs(ns.Coefficients_mult[k]) * b.Lipschitz[l+1][n] * b.Gain[l+1][n][j]
+				}
+			}
+		}
+	}
+
+	b.Inputs = make([]float64, outputs)
+	for n, ns := range m.Layers[0].Nodes {
+		for k := range ns.Input {
+			for j := range b.Inputs {
+				b.Inputs[j] += math.Abs(ns.Coefficients_mult[k]) * b.Lipschitz[0][n] * b.Gain[0][n][j] * noise.Fresh
+			}
+		}
+	}
+
+	b.Outputs = append([]float64(nil), b.Inputs...)
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			for j := range b.Outputs {
+				b.Outputs[j] += (b.Approximation[l][i] + b.Noise[l][i]) * b.Gain[l][i][j]
+			}
+		}
+	}
+	return b
+}
+
+// Ranking returns the contribution of every node to the error of output j, or
+// to the sum of the errors of the outputs if j is negative, largest first.
+func (b Budget) Ranking(j int) (ranking []Contribution) {
+	for l := range b.Gain {
+		for i := range b.Gain[l] {
+			gain := 0.0
+			for o, g := range b.Gain[l][i] {
+				if j < 0 || o == j {
+					gain += g
+				}
+			}
+			ranking = append(ranking, Contribution{
+				Layer:         l,
+				Node:          i,
+				Approximation: b.Approximation[l][i] * gain,
+				Noise:         b.Noise[l][i] * gain,
+			})
+		}
+	}
+	sort.SliceStable(ranking, func(a, c int) bool { return ranking[a].Total() > ranking[c].Total() })
+	return ranking
+}
+
+// approximationError samples the polynomial p of the node, computed on its
+// interval, at the midpoints of points cells of [lo, hi], and returns
+// max |f - p|, max |p'| and the sum of the absolute values of the coefficients
+// of p.
+func (ns NodeSpec) approximationError(lo, hi float64, points int) (approx, lipschitz, norm float64) {
+
+	f := ns.Function()
+	a, b := ns.Interval[0], ns.Interval[1]
+	poly := ns.Approximator()(a, b, ns.Degree, f)
+	coeffs := make([]float64, len(poly.Coeffs))
+	for i, c := range poly.Coeffs {
+		coeffs[i], _ = c.Real().Float64()
+		norm += math.Abs(coeffs[i])
+	}
+
+	step := (hi - lo) / float64(points)
+	previous := 0.0
+	for k := 0; k < points; k++ {
+		x := lo + (float64(k)+0.5)*step
+		y := chebyshev(coeffs, (2*x-a-b)/(b-a))
+		approx = math.Max(approx, math.Abs(f(x)-y))
+		if k > 0 {
+			lipschitz = math.Max(lipschitz, math.Abs(y-previous)/step)
+		}
+		previous = y
+	}
+	return approx, lipschitz, norm
+}
+
+// chebyshev evaluates sum coeffs[i] T_i(t) for t in [-1, 1]. It stands for
+// bignum.Polynomial.Evaluate, which shifts the imaginary part of its input
+// as well and so is off on intervals not centered on 0.
+func chebyshev(coeffs []float64, t float64) float64 {
+	var b1, b2 float64
+	for i := len(coeffs) - 1; i > 0; i-- {
+		b1, b2 = 2*t*b1-b2+coeffs[i], b1
+	}
+	return t*b1 - b2 + coeffs[0]
+}
+
+// bounded reports whether bound is a nonempty interval of finite width.
+func bounded(bound []float64) bool {
+	lo, hi := bound[0], bound[1]
+	return !math.IsInf(lo, 0) && !math.IsInf(hi, 0) && !math.IsNaN(lo) && !math.IsNaN(hi) && lo < hi
+}
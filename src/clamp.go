@@ -0,0 +1,88 @@
+package src
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// ClampRange is the least half-width of the range a clamp is bounded on, in
+// half-widths of the interval of its node and around its center. The range
+// reaches farther where the inner product of the node does on the declared
+// feature ranges, see Model.Reach.
+var ClampRange = 2.0
+
+// ClampDegree is the degree of the clamp polynomial. On a range of 2, degree 31
+// moves inputs by at most 0.3% of the half-width of the interval in its
+// central 80%, and 1.5% at its ends; on a range of 4, by 2% and 2.3%, and on
+// 16, by 4% and 8%. It stays within 10% of the interval on its whole range.
+var ClampDegree = 31
+
+// clamp maps the inner product of the node into the interval before its
+// activation, where a polynomial would extrapolate. The clip of t, the
+// input rescaled to [-1, 1] on the interval, to [-1, 1] has kinks at -1 and 1
+// that a polynomial follows poorly; its convolution with a gaussian of width
+// sigma is analytic and stays in [-1, 1], and is close to t inside the
+// interval but for the last few sigma. A single polynomial of ClampDegree
+// approximates it on n.Reach, sigma chosen on a plaintext simulation to
+// minimize the error on the interval plus the overshoot beyond [-1, 1].
+func (n Node) clamp(interval []float64, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+	clip := n
+	clip.Activation = smoothClamp(interval, n.Reach)
+	clip.Approximation = GetChebyshevPoly
+	return PolynomialStrategy{}.Evaluate(clip, clampInterval(interval, n.Reach), ClampDegree, budget, ev)
+}
+
+// ClampDepth returns the levels a clamp consumes at most: those of its
+// polynomial and of the inner product fused with its change of basis.
+func ClampDepth() int {
+	return bits.Len(uint(ClampDegree)) + 1
+}
+
+// clampInterval returns the range of reach half-widths around the interval.
+func clampInterval(interval []float64, reach float64) []float64 {
+	center, half := (interval[0]+interval[1])/2, (interval[1]-interval[0])/2
+	return []float64{center - reach*half, center + reach*half}
+}
+
+// smoothClamp returns the smooth clip into the interval of a clamp on reach.
+func smoothClamp(interval []float64, reach float64) func(float64) float64 {
+	center, half := (interval[0]+interval[1])/2, (interval[1]-interval[0])/2
+	g := smoothClip(clampSigma(reach))
+	return func(x float64) float64 { return center + half*g((x-center)/half) }
+}
+
+// smoothClip returns the clip of t to [-1, 1] convolved with a gaussian of
+// width sigma, as the difference of the convolutions of |t + 1| and |t - 1|.
+func smoothClip(sigma float64) func(float64) float64 {
+	abs := func(u float64) float64 {
+		return u*math.Erf(u/(sigma*math.Sqrt2)) + sigma*math.Sqrt(2/math.Pi)*math.Exp(-u*u/(2*sigma*sigma))
+	}
+	return func(t float64) float64 { return (abs(t+1) - abs(t-1)) / 2 }
+}
+
+// clampSigma returns the width of the gaussian of smoothClip for a clamp on
+// reach at ClampDegree: a narrow one keeps the interval but is approximated
+// poorly.
+func clampSigma(reach float64) float64 {
+	inside := grid(-1, 1, 1024)
+	outside := grid(-reach, reach, 4096)
+	best, sigma := math.Inf(1), reach
+	for j := 0; j < 40; j++ {
+		s := reach * math.Exp2(-float64(j)/4)
+		p := interpolant(-reach, reach, ClampDegree, smoothClip(s))
+		e := 0.0
+		for _, t := range inside {
+			e = math.Max(e, math.Abs(p(t)-t))
+		}
+		overshoot := 0.0
+		for _, t := range outside {
+			overshoot = math.Max(overshoot, math.Abs(p(t))-1)
+		}
+		if e += overshoot; e < best {
+			best, sigma = e, s
+		}
+	}
+	return sigma
+}
@@ -0,0 +1,27 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+// TestClamp runs a clamped identity node, on [-4, 4], through Model.Forward on
+// slots inside its interval and one far outside it, within the declared range
+// of its feature, for a reach of 3. The clamp must bring that slot to the end
+// of the interval and leave the others, within the 2% of the half-width that
+// ClampDegree documents on a range of 4.
+func TestClamp(t *testing.T) {
+
+	k := newTestKeys(t)
+	m := passThrough([][]float64{{-12, 12}})
+	m.Layers[0].Nodes[0].Interval = []float64{-4, 4}
+	m.Layers[0].Nodes[0].Clamp = true
+	values := []float64{-3.2, -2, -0.5, 0, 0.7, 1.5, 3.2, 11.5}
+	want := []float64{-3.2, -2, -0.5, 0, 0.7, 1.5, 3.2, 4}
+	got := k.decrypt(t, m.Forward(k.encrypt(t, [][]float64{values}), k.eval, k.eval_boot, k.params)[0], len(values))
+	for i := range got {
+		if e := math.Abs(got[i] - want[i]); !(e < 0.02*4) {
+			t.Errorf("%g clamped to %g, want %g", values[i], got[i], want[i])
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package src
 
+import "github.com/JohnJimAir/asimpnetwork/dataset"
+
 // 1988.48 * exp(
 // 	0.13*(    0.39*sin(7.07*x_2 - 6.21) + 0.09*sin(9.52*x_3 - 8.15)  + 0.21*sin(3.64*x_5 - 0.62) - 0.13*sin(2.24*x_6 + 8.2)   + 0.01*sin(7.85*x_8 + 7.58) + 0.08*tanh(3.77*x_1 - 1.01) + 0.19*tanh(10.0*x_7 - 8.2)   - 0.e-2*Abs(9.96*x_4 - 3.26) + 0.01*Abs(7.94*x_9 - 0.2) - 1)**2 -
 // 	0.09*sin( 2.28*(0.33 - x_3)**2      + 1.38*sin(7.4*x_2 + 1.19)   + 1.64*sin(6.44*x_5 - 2.23) + 0.72*sin(6.11*x_6 - 0.73)  - 0.37*sin(5.2*x_7 + 1.18)  - 0.87*sin(4.95*x_8 + 9.62)  + 0.27*tanh(9.6*x_4 - 2.47)   + 0.29*tanh(5.89*x_9 - 2.45) + 5.55) +
@@ -13,4 +15,115 @@ package src
 // 	7.04*sin( 0.05*(0.24 - x_7)**2      - 0.3*(0.37 - x_8)**3        - 0.61*(0.43 - x_1)**3      - 0.01*sin(5.08*x_2 - 2.22)  - 0.04*sin(6.62*x_3 + 2.99) + 0.05*sin(7.21*x_4 - 5.79)  - 0.e-2*tan(2.2*x_5 - 9.64)   + 0.08*tan(0.28*x_9 + 1.0)   + 0.07*tanh(3.24*x_6 - 2.6) + 4.04) -
 // 	0.03*Abs( 21.1*sin(3.89*x_3 - 7.86) + 19.17*sin(3.86*x_4 - 8.02) + 4.29*sin(3.65*x_7 - 1.43) + 12.29*sin(9.79*x_8 + 4.21) + 2.98*tan(1.13*x_1 - 9.75) + 2.38*tan(1.49*x_5 + 2.53)  + 25.59*tanh(3.94*x_2 - 0.58) + 12.94*tanh(10.0*x_6 - 2.6) + 9.55*tanh(7.8*x_9 - 0.84) + 85.59)
 //   + 10.72
-// )
\ No newline at end of file
+// )
+
+// BreastCancerModel returns the KAN above. The top layer holds the four
+// blocks of cmd/ciphertext one after the other, so node 9*k + i of the first
+// layer is block k applied to x_(i+1). Zero weights (the -0.e-2 terms and the
+// missing x_1 term of the second block) are kept as edges of weight 0.
+// Intervals and degrees are those of cmd/ciphertext, except the Abs of the
+// fourth middle node, whose input spans -4.3 to 182.42
+// (cmd/plaintext_rearrange/bound/bound_output.txt), and the tan nodes, which
+// cmd/ciphertext replaces by contract and which are given intervals around
+// their input range that avoid the poles.
+func BreastCancerModel() Model {
+
+	K := 16.0
+	I := []float64{-K, K}
+	top := func(feature int, w, b float64, activation string, interval []float64) NodeSpec {
+		return NodeSpec{
+			Input:             []int{feature},
+			Coefficients_mult: []float64{w},
+			Coefficient_add:   b,
+			Activation:        activation,
+			Interval:          interval,
+			Degree:            31,
+		}
+	}
+	block := func(k int) []int {
+		return []int{9 * k, 9*k + 1, 9*k + 2, 9*k + 3, 9*k + 4, 9*k + 5, 9*k + 6, 9*k + 7, 9*k + 8}
+	}
+
+	preprocessing := make(Pipeline, 0)
+	ranges := make([][]float64, 0)
+	for _, name := range dataset.BreastCancer.Features {
+		// integer scores from 1 to 10, as in the UCI Breast Cancer Wisconsin
+		// (Original) dataset, mapped to [0, 1]
+		preprocessing = append(preprocessing, Step{Kind: "minmax", Column: name, Min: 1, Max: 10})
+		ranges = append(ranges, []float64{0, 1})
+	}
+
+	return Model{
+		Name:          "breast-cancer",
+		Features:      append([]string(nil), dataset.BreastCancer.Features...),
+		Ranges:        ranges,
+		Preprocessing: preprocessing,
+		Layers: []Layer{
+			{
+				Nodes: []NodeSpec{
+					top(0, 3.77, -1.01, "tanh", I),
+					top(1, 7.07, -6.21, "sin", I),
+					top(2, 9.52, -8.15, "sin", I),
+					top(3, 9.96, -3.26, "abs", []float64{-8, 8}),
+					top(4, 3.64, -0.62, "sin", I),
+					top(5, 2.24, 8.2, "sin", I),
+					top(6, 10.0, -8.2, "tanh", I),
+					top(7, 7.85, 7.58, "sin", I),
+					top(8, 7.94, -0.2, "abs", []float64{-8, 8}),
+
+					top(0, 1.0, 0.0, "identity", I),
+					top(1, 7.4, 1.19, "sin", I),
+					top(2, -1.0, 0.33, "pow2", I),
+					top(3, 9.6, -2.47, "tanh", I),
+					top(4, 6.44, -2.23, "sin", I),
+					top(5, 6.11, -0.73, "sin", I),
+					top(6, 5.2, 1.18, "sin", I),
+					top(7, 4.95, 9.62, "sin", I),
+					top(8, 5.89, -2.45, "tanh", I),
+
+					top(0, -1.0, 0.43, "pow3", I),
+					top(1, 5.08, -2.22, "sin", I),
+					top(2, 6.62, 2.99, "sin", I),
+					top(3, 7.21, -5.79, "sin", I),
+					top(4, 2.2, -9.64, "tan", []float64{-9.7, -7.9}), // weight 0; the pole at -7.85 is cut off
+					top(5, 3.24, -2.6, "tanh", I),
+					top(6, -1.0, 0.24, "pow2", I),
+					top(7, -1.0, 0.37, "pow3", I),
+					top(8, 0.28, 1.0, "tan", []float64{0.9, 1.4}),
+
+					top(0, 1.13, -9.75, "tan", []float64{-9.8, -8.5}),
+					top(1, 3.94, -0.58, "tanh", I),
+					top(2, 3.89, -7.86, "sin", I),
+					top(3, 3.86, -8.02, "sin", I),
+					top(4, 1.49, 2.53, "tan", []float64{2.4, 4.1}),
+					top(5, 10.0, -2.6, "tanh", I),
+					top(6, 3.65, -1.43, "sin", I),
+					top(7, 9.79, 4.21, "sin", I),
+					top(8, 7.8, -0.84, "tanh", I),
+				},
+				Bootstrap: true,
+			},
+			{
+				Nodes: []NodeSpec{
+					{Input: block(0), Coefficients_mult: []float64{0.08, 0.39, 0.09, 0.0, 0.21, -0.13, 0.19, 0.01, 0.01}, Coefficient_add: -1.0, Activation: "pow2", Interval: I, Degree: 31},
+					{Input: block(1), Coefficients_mult: []float64{0.0, 1.38, 2.28, 0.27, 1.64, 0.72, -0.37, -0.87, 0.29}, Coefficient_add: 5.55, Activation: "sin", Interval: I, Degree: 31},
+					{Input: block(2), Coefficients_mult: []float64{-0.61, -0.01, -0.04, 0.05, 0.0, 0.07, 0.05, -0.3, 0.08}, Coefficient_add: 4.04, Activation: "sin", Interval: I, Degree: 31},
+					{Input: block(3), Coefficients_mult: []float64{2.98, 25.59, 21.1, 19.17, 2.38, 12.94, 4.29, 12.29, 9.55}, Coefficient_add: 85.59, Activation: "abs", Interval: []float64{-8, 192}, Degree: 31},
+				},
+				Bootstrap: true,
+			},
+			{
+				Nodes: []NodeSpec{
+					{Input: []int{0, 1, 2, 3}, Coefficients_mult: []float64{0.13, -0.09, 2.93, -0.01}, Coefficient_add: 0.0, Activation: "exp", Interval: []float64{-8, 8}, Degree: 31},
+					{Input: []int{0, 1, 2, 3}, Coefficients_mult: []float64{0.31, -0.21, 7.04, -0.03}, Coefficient_add: 10.72, Activation: "tanh", Interval: []float64{-8, 8}, Degree: 31},
+				},
+			},
+			{
+				Nodes: []NodeSpec{
+					{Input: []int{0}, Coefficients_mult: []float64{1988.48}, Coefficient_add: -31.97, Activation: "identity", Interval: []float64{-8, 8}, Degree: 1},
+					{Input: []int{1}, Coefficients_mult: []float64{-7.34}, Coefficient_add: 1.99, Activation: "identity", Interval: []float64{-8, 8}, Degree: 1},
+				},
+			},
+		},
+	}
+}
@@ -1,5 +1,7 @@
 package src
 
+import "github.com/JohnJimAir/asimpnetwork/dataset"
+
 // 1.04 - 1.05*sin( 0.04*(-x_1 - 0.72)**2 - 0.28*sqrt(x_11 + 0.37) + 0.05*log(4.25 - 1.38*x_16) - 0.24*log(3.4*x_14 + 3.95) +
 // 	0.12*sin(0.27*x_17 + 1.85) - 0.12*sin(0.31*x_19 + 5.04) + 0.02*sin(0.89*x_20 - 0.18) - 0.49*sin(0.43*x_22 + 2.24) +
 // 	0.13*sin(0.41*x_23 + 2.39) + 0.24*sin(0.31*x_24 + 1.61) + 0.35*sin(0.16*x_25 - 4.2) + 0.13*sin(0.18*x_26 - 7.56) +
@@ -7,4 +9,112 @@ package src
 // 	0.18*sin(0.26*x_35 + 2.15) - 0.06*sin(0.28*x_36 - 7.78) + 0.04*sin(0.26*x_37 + 4.52) + 0.05*sin(1.06*x_8 - 9.61) +
 // 	0.1*tan(0.28*x_10 - 5.95) + 0.01*tan(0.14*x_4 + 1.0) - 0.07*tanh(0.58*x_2 - 0.48) + 0.03*tanh(0.95*x_21 - 0.53) +
 // 	0.02*tanh(1.02*x_28 - 0.68) - 0.11*tanh(0.35*x_31 - 1.43) + 0.04*tanh(1.22*x_32 - 2.35) + 0.03*tanh(1.51*x_33 - 2.12) -
-// 	0.32*tanh(0.2*x_5 - 0.85) + 0.02*Abs(9.96*x_18 + 7.21) - 0.01*Abs(6.07*x_34 + 2.42) + 5.76)
\ No newline at end of file
+// 	0.32*tanh(0.2*x_5 - 0.85) + 0.02*Abs(9.96*x_18 + 7.21) - 0.01*Abs(6.07*x_34 + 2.42) + 5.76)
+
+// SepsisModel returns the KAN above, with node i of the first layer applied
+// to x_(i+1). Features without a term are identity nodes of weight 0.
+// Intervals and degrees are those of cmd/cipher_sepsis, except the two Abs
+// nodes, whose inputs span -1.95 to 18.03 and -2.1 to 56.08
+// (cmd/plain_sepsis), the two tan nodes, which cmd/cipher_sepsis replaces by
+// contract and which are given intervals that avoid the poles, and the sqrt
+// and log nodes, whose intervals must be positive for their strategies: the
+// empirical bounds of their inputs on data/test_data_sepsis.csv, 0.22 to 7.07,
+// 2.69 to 13.14 and 0.18 to 4.72, widened. The export is standardized
+// upstream and the statistics are not part of this tree, so the model carries
+// no preprocessing step.
+func SepsisModel() Model {
+
+	K := 16.0
+	I := []float64{-K, K}
+	top := func(feature int, w, b float64, activation string, interval []float64) NodeSpec {
+		return NodeSpec{
+			Input:             []int{feature},
+			Coefficients_mult: []float64{w},
+			Coefficient_add:   b,
+			Activation:        activation,
+			Interval:          interval,
+			Degree:            31,
+		}
+	}
+	none := func(feature int) NodeSpec {
+		return top(feature, 1.0, 0.0, "identity", I)
+	}
+
+	all := make([]int, 37)
+	for i := range all {
+		all[i] = i
+	}
+
+	return Model{
+		Name:     "sepsis",
+		Features: append([]string(nil), dataset.Sepsis.Features...),
+		Layers: []Layer{
+			{
+				Nodes: []NodeSpec{
+					top(0, -1.0, -0.72, "pow2", I),
+					top(1, 0.58, -0.48, "tanh", I),
+					top(2, 0.4, 1.37, "sin", I),
+					top(3, 0.14, 1.0, "tan", []float64{0.7, 1.5}),
+					top(4, 0.2, -0.85, "tanh", I),
+					none(5),
+					none(6),
+					top(7, 1.06, -9.61, "sin", I),
+					none(8),
+					top(9, 0.28, -5.95, "tan", []float64{-6.1, -5.8}),
+					top(10, 1.0, 0.37, "sqrt", []float64{0.1, 8}),
+					none(11),
+					none(12),
+					top(13, 3.4, 3.95, "log", []float64{1.5, 16}),
+					none(14),
+					top(15, -1.38, 4.25, "log", []float64{0.1, 6}),
+					top(16, 0.27, 1.85, "sin", I),
+					top(17, 9.96, 7.21, "abs", []float64{-4, 20}),
+					top(18, 0.31, 5.04, "sin", I),
+					top(19, 0.89, -0.18, "sin", I),
+					top(20, 0.95, -0.53, "tanh", I),
+					top(21, 0.43, 2.24, "sin", I),
+					top(22, 0.41, 2.39, "sin", I),
+					top(23, 0.31, 1.61, "sin", I),
+					top(24, 0.16, -4.2, "sin", I),
+					top(25, 0.18, -7.56, "sin", I),
+					top(26, 0.17, 8.5, "sin", I),
+					top(27, 1.02, -0.68, "tanh", I),
+					top(28, 0.21, 2.16, "sin", I),
+					top(29, 0.23, -7.04, "sin", I),
+					top(30, 0.35, -1.43, "tanh", I),
+					top(31, 1.22, -2.35, "tanh", I),
+					top(32, 1.51, -2.12, "tanh", I),
+					top(33, 6.07, 2.42, "abs", []float64{-4, 60}),
+					top(34, 0.26, 2.15, "sin", I),
+					top(35, 0.28, -7.78, "sin", I),
+					top(36, 0.26, 4.52, "sin", I),
+				},
+				Bootstrap: true,
+			},
+			{
+				Nodes: []NodeSpec{
+					{
+						Input: all,
+						Coefficients_mult: []float64{0.04, -0.07, -0.05, 0.01, -0.32,
+							0.0, 0.0, 0.05, 0.0, 0.1,
+							-0.28, 0.0, 0.0, -0.24, 0.0,
+							0.05, 0.12, 0.02, -0.12, 0.02,
+							0.03, -0.49, 0.13, 0.24, 0.35,
+							0.13, 0.5, 0.02, 0.13, -0.21,
+							-0.11, 0.04, 0.03, -0.01, 0.18,
+							-0.06, 0.04},
+						Coefficient_add: 5.76,
+						Activation:      "sin",
+						Interval:        I,
+						Degree:          31,
+					},
+				},
+			},
+			{
+				Nodes: []NodeSpec{
+					{Input: []int{0}, Coefficients_mult: []float64{-1.05}, Coefficient_add: 1.04, Activation: "identity", Interval: I, Degree: 1},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package src
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+)
+
+// DecisionChain is the index in SignChains of the steps of Decide and Argmax.
+var DecisionChain = len(SignChains) - 1
+
+// OutputMargin widens the OutputIntervals by this fraction of their width on
+// each side, for the approximation and CKKS errors of the encrypted outputs.
+var OutputMargin = 0.05
+
+// OutputIntervals returns an enclosure of each encrypted output of the model
+// for features in their declared ranges: the bounds of DeclaredBounds on the
+// outputs, widened by OutputMargin. It fails on unbounded outputs.
+func (m Model) OutputIntervals() (intervals [][]float64, err error) {
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("model without layers")
+	}
+	b, errs := m.DeclaredBounds()
+	last := b.Post[len(b.Post)-1]
+	intervals = make([][]float64, len(last))
+	for i, bound := range last {
+		lo, hi := bound[0], bound[1]
+		if math.IsInf(lo, 0) || math.IsInf(hi, 0) || math.IsNaN(lo) || math.IsNaN(hi) {
+			return nil, fmt.Errorf("output %d is unbounded on the declared feature ranges %v (%v)", i, bound, errs)
+		}
+		pad := OutputMargin * (hi - lo)
+		intervals[i] = []float64{lo - pad, hi + pad}
+	}
+	return intervals, nil
+}
+
+// Decide returns, per slot, the encrypted label of the outputs of the model:
+// 1 where output[1] - output[0] > threshold for two outputs, as the margin of
+// cmd/accuracy, or output[0] > threshold for one. The label is the step at
+// threshold of the score on its range, from OutputIntervals, by the chain
+// DecisionChain of SignChains, bootstrapped between compositions as needed.
+// Scores within a fraction of a percent of the range from the threshold get a
+// soft label, which the client rounds.
+func (m Model) Decide(output []*rlwe.Ciphertext, threshold float64, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (label *rlwe.Ciphertext) {
+
+	if m.IsRegression() {
+		panic(fmt.Errorf("decision on the predictions of a regression model"))
+	}
+	intervals, err := m.OutputIntervals()
+	if err != nil {
+		panic(err)
+	}
+	var score *rlwe.Ciphertext
+	var interval []float64
+	switch len(output) {
+	case 1:
+		score = output[0].CopyNew()
+		interval = intervals[0]
+	case 2:
+		score = Innerproduct([]float64{-1, 1}, 0, output, eval)
+		interval = []float64{intervals[1][0] - intervals[0][1], intervals[1][1] - intervals[0][0]}
+	default:
+		panic(fmt.Errorf("decision on %d outputs, use Argmax", len(output)))
+	}
+	if math.IsInf(interval[0], 0) || math.IsInf(interval[1], 0) || !(threshold > interval[0] && threshold < interval[1]) {
+		panic(fmt.Errorf("decision at %g on the score range %v", threshold, interval))
+	}
+	return compare(score, interval, threshold, 0, eval, eval_boot, params)
+}
+
+// compare returns the step at threshold of score, whose values lie in the
+// interval, by the chain DecisionChain of SignChains, leaving reserve levels
+// on its output. A score without the levels of the first polynomial is
+// bootstrapped first, divided into [-1, 1] by raising its scale, at no level,
+// since bootstrapping only holds small values; the score is consumed. The
+// compositions that follow run on [-1, 1] and are bootstrapped whenever
+// their levels run out.
+func compare(score *rlwe.Ciphertext, interval []float64, threshold float64, reserve int, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (output *rlwe.Ciphertext) {
+
+	polys := stepPolynomials(threshold, interval, SignChains[DecisionChain])
+	first := PolynomialStrategy{}.Depth([]float64{1}, interval, 7, math.MaxInt)
+	if len(polys) == 1 {
+		first += reserve
+	}
+	if score.Level() < first {
+		largest := math.Max(math.Abs(interval[0]), math.Abs(interval[1]))
+		score.Scale = score.Scale.Mul(rlwe.NewScale(largest))
+		score = Bootstrap(eval_boot, []*rlwe.Ciphertext{score})[0]
+		scaled := polys[0]
+		polys[0] = func(x float64) float64 { return scaled(x * largest) }
+		interval = []float64{interval[0] / largest, interval[1] / largest}
+	}
+
+	ev := NewEvaluation(eval, params)
+	n := Node{
+		Coefficients_mult: []float64{1},
+		Activation:        polys[0],
+		Input:             []*rlwe.Ciphertext{score},
+		Approximation:     GetChebyshevPoly,
+	}
+	output, _ = PolynomialStrategy{}.Evaluate(n, interval, 7, math.MaxInt, ev)
+	for k, f := range polys[1:] {
+		need := 3
+		if k == len(polys)-2 {
+			need += reserve
+		}
+		if output.Level() < need {
+			output = Bootstrap(eval_boot, []*rlwe.Ciphertext{output})[0]
+		}
+		next := Node{
+			Coefficients_mult: []float64{1},
+			Activation:        f,
+			Input:             []*rlwe.Ciphertext{output},
+			Approximation:     GetChebyshevPoly,
+		}
+		output, _ = PolynomialStrategy{}.Evaluate(next, []float64{-1, 1}, 7, math.MaxInt, ev)
+	}
+	return output
+}
@@ -0,0 +1,175 @@
+package src
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils"
+)
+
+// testKeys holds the parameters and keys of the encrypted tests: those of the
+// examples at the insecure ring degree of -short.
+type testKeys struct {
+	params    hefloat.Parameters
+	eval      *hefloat.Evaluator
+	eval_boot *bootstrapping.Evaluator
+	encoder   *hefloat.Encoder
+	encryptor *rlwe.Encryptor
+	decryptor *rlwe.Decryptor
+}
+
+var (
+	keysOnce sync.Once
+	keys     testKeys
+	keysErr  error
+)
+
+// newTestKeys generates the keys once for all the tests, and skips the tests
+// that need them in short mode.
+func newTestKeys(t *testing.T) testKeys {
+
+	if testing.Short() {
+		t.Skip("key generation of the bootstrapping")
+	}
+	keysOnce.Do(func() {
+		LogN := 13
+		params, err := hefloat.NewParametersFromLiteral(hefloat.ParametersLiteral{
+			LogN:            LogN,
+			LogQ:            []int{55, 40, 40, 40, 40, 40, 40, 40, 40, 40, 40},
+			LogP:            []int{61, 61, 61},
+			LogDefaultScale: 40,
+			Xs:              ring.Ternary{H: 192},
+		})
+		if err != nil {
+			keysErr = err
+			return
+		}
+		btpParams, err := bootstrapping.NewParametersFromLiteral(params, bootstrapping.ParametersLiteral{
+			LogN: utils.Pointy(LogN),
+			LogP: []int{61, 61, 61, 61},
+			Xs:   params.Xs(),
+		})
+		if err != nil {
+			keysErr = err
+			return
+		}
+		btpParams.Mod1ParametersLiteral.LogMessageRatio += 16 - params.LogN()
+
+		kgen := rlwe.NewKeyGenerator(params)
+		sk, pk := kgen.GenKeyPairNew()
+		evk_boot, _, err := btpParams.GenEvaluationKeys(sk)
+		if err != nil {
+			keysErr = err
+			return
+		}
+		eval_boot, err := bootstrapping.NewEvaluator(btpParams, evk_boot)
+		if err != nil {
+			keysErr = err
+			return
+		}
+		keys = testKeys{
+			params:    params,
+			eval:      hefloat.NewEvaluator(params, rlwe.NewMemEvaluationKeySet(kgen.GenRelinearizationKeyNew(sk))),
+			eval_boot: eval_boot,
+			encoder:   hefloat.NewEncoder(params),
+			encryptor: rlwe.NewEncryptor(params, pk),
+			decryptor: rlwe.NewDecryptor(params, sk),
+		}
+	})
+	if keysErr != nil {
+		t.Fatal(keysErr)
+	}
+	return keys
+}
+
+// encrypt returns one fresh ciphertext per slice of values.
+func (k testKeys) encrypt(t *testing.T, values [][]float64) (cts []*rlwe.Ciphertext) {
+	for _, v := range values {
+		pt := hefloat.NewPlaintext(k.params, k.params.MaxLevel())
+		if err := k.encoder.Encode(v, pt); err != nil {
+			t.Fatal(err)
+		}
+		ct, err := k.encryptor.EncryptNew(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, ct)
+	}
+	return cts
+}
+
+// decrypt returns the first num slots of ct.
+func (k testKeys) decrypt(t *testing.T, ct *rlwe.Ciphertext, num int) []float64 {
+	values := make([]float64, k.params.MaxSlots())
+	if err := k.encoder.Decode(k.decryptor.DecryptNew(ct), values); err != nil {
+		t.Fatal(err)
+	}
+	return values[:num]
+}
+
+// passThrough returns a model whose outputs are its features, declared in
+// ranges, so that encrypted values stand for its outputs.
+func passThrough(ranges [][]float64) Model {
+	m := Model{Name: "pass-through", Ranges: ranges}
+	layer := Layer{}
+	for j, r := range ranges {
+		m.Features = append(m.Features, string(rune('a'+j)))
+		layer.Nodes = append(layer.Nodes, NodeSpec{
+			Input:             []int{j},
+			Coefficients_mult: []float64{1},
+			Activation:        "identity",
+			Interval:          r,
+			Degree:            1,
+		})
+	}
+	m.Layers = []Layer{layer}
+	return m
+}
+
+func TestDecide(t *testing.T) {
+
+	k := newTestKeys(t)
+	for _, tc := range []struct {
+		name      string
+		ranges    [][]float64
+		outputs   [][]float64
+		threshold float64
+	}{
+		{
+			"margin of two outputs",
+			[][]float64{{-2, 3}, {-2, 3}},
+			[][]float64{{-1, 2, 0.5, 2.5, -2, 0}, {1, -1, 0.3, 2.9, 3, -0.2}},
+			0,
+		},
+		{
+			"single output",
+			[][]float64{{0, 1}},
+			[][]float64{{0.1, 0.45, 0.55, 0.9, 0, 1}},
+			0.5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := passThrough(tc.ranges)
+			label := m.Decide(k.encrypt(t, tc.outputs), tc.threshold, k.eval, k.eval_boot, k.params)
+			got := k.decrypt(t, label, len(tc.outputs[0]))
+			for i := range got {
+				score := tc.outputs[0][i]
+				if len(tc.outputs) == 2 {
+					score = tc.outputs[1][i] - tc.outputs[0][i]
+				}
+				want := 0.0
+				if score > tc.threshold {
+					want = 1
+				}
+				if math.Abs(got[i]-want) > 0.01 {
+					t.Errorf("slot %d: score %g, label %g, want %g", i, score, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package src
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+)
+
+// DomainDegree is the degree of the first polynomial of the out-of-domain
+// indicators of Block.OutOfDomain.
+var DomainDegree = 31
+
+// ForwardFlagged evaluates the model as Forward and also returns, per slot, a
+// soft OR of the out-of-domain indicators of the nodes: their sum, close to 0
+// if the affine input of every node stayed in its interval and at least close
+// to 1 otherwise. Nodes whose approximation is Exact beyond their interval
+// have no indicator. The indicators of a layer, see Block.OutOfDomain, are
+// computed on its inputs, and their sum consumes no level. Decrypted, Flagged
+// tells the predictions to discard. It panics before evaluating if the model
+// does not Fit the levels of params, or if the inner product of a node with
+// an indicator is unbounded on the declared feature ranges.
+func (m Model) ForwardFlagged(input []*rlwe.Ciphertext, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (output []*rlwe.Ciphertext, flag *rlwe.Ciphertext) {
+
+	if err := m.Fits(params.MaxLevel()); err != nil {
+		panic(err)
+	}
+	reserves, reach := m.Reserves(params.MaxLevel()), m.Reach()
+	exact := make([][]bool, len(m.Layers))
+	for l, layer := range m.Layers {
+		exact[l] = make([]bool, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			exact[l][i] = ns.Exact()
+			if !exact[l][i] && (math.IsInf(reach[l][i], 0) || math.IsNaN(reach[l][i])) {
+				panic(fmt.Errorf("layer %d node %d: out-of-domain indicator of an unbounded inner product", l, i))
+			}
+		}
+	}
+
+	output = input
+	for l, layer := range m.Layers {
+		bl, intervals, degrees := layer.Block(output)
+		bl.setup(reserves[l], reach[l], eval_boot)
+		for _, indicator := range bl.OutOfDomain(intervals, exact[l], eval, eval_boot, params) {
+			if indicator != nil {
+				flag = add(flag, indicator, eval)
+			}
+		}
+		output = bl.Forward(intervals, degrees, eval, params)
+		if layer.Bootstrap {
+			output = Bootstrap(eval_boot, output)
+		}
+	}
+	return output, flag
+}
+
+// Flagged reports whether a decrypted value of the flag of ForwardFlagged
+// marks its slot as out of domain: whether it is away from 0.
+func Flagged(flag float64) bool {
+	return !(math.Abs(flag) < 0.5)
+}
+
+// OutOfDomain returns, per slot and node, a soft indicator that the affine
+// input x of the node leaves its interval, nil for the nodes marked in skip.
+// With t = (x - c)/h, for the center c and half-width h of the interval, the
+// indicator is the step at 1 of t^2 on the Reach R of the node, by the sign
+// chain of Cheon, Kim and Kim: f3(g3(u)) with u = (t^2 - 1)/(R^2 - 1),
+// approximated by a single polynomial of DomainDegree, then f3 once more. It
+// stays in [0, 1] for |t| up to R, so that an input within the declared
+// feature ranges cannot overflow. For R = 2, it is below 1e-3 for |t| < 0.8,
+// 0.05 at 0.9, 0.5 at 1 and above 0.95 from 1.1; for a larger R it is softer
+// around 1. Inputs without the levels of the indicators are bootstrapped
+// first, leaving those of the block untouched.
+func (bl Block) OutOfDomain(intervals [][]float64, skip []bool, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (indicators []*rlwe.Ciphertext) {
+
+	refreshed := make(map[*rlwe.Ciphertext]*rlwe.Ciphertext)
+	ev := NewEvaluation(eval, params)
+	indicators = make([]*rlwe.Ciphertext, bl.Num_node)
+	for i, n := range bl.Nodes {
+		if skip[i] {
+			continue
+		}
+		interval := clampInterval(intervals[i], n.Reach)
+		depth := PolynomialStrategy{}.Depth(n.Coefficients_mult, interval, DomainDegree, math.MaxInt) + 3
+		level := math.MaxInt
+		for _, ct := range n.Input {
+			level = min(level, ct.Level())
+		}
+		if level < depth {
+			input := make([]*rlwe.Ciphertext, len(n.Input))
+			for k, ct := range n.Input {
+				if refreshed[ct] == nil {
+					refreshed[ct] = Bootstrap(eval_boot, []*rlwe.Ciphertext{ct.CopyNew()})[0]
+				}
+				input[k] = refreshed[ct]
+			}
+			n.Input = input
+		}
+
+		center, half, reach := (intervals[i][0]+intervals[i][1])/2, (intervals[i][1]-intervals[i][0])/2, n.Reach
+		first := n
+		first.Activation = func(x float64) float64 {
+			t := (x - center) / half
+			return f3(g3((t*t - 1) / (reach*reach - 1)))
+		}
+		first.Approximation = GetChebyshevPoly
+		sign, _ := PolynomialStrategy{}.Evaluate(first, interval, DomainDegree, math.MaxInt, ev)
+
+		last := Node{
+			Coefficients_mult: []float64{1},
+			Activation:        func(s float64) float64 { return (f3(s) + 1) / 2 },
+			Input:             []*rlwe.Ciphertext{sign},
+			Approximation:     GetChebyshevPoly,
+		}
+		indicators[i], _ = PolynomialStrategy{}.Evaluate(last, []float64{-1, 1}, 7, math.MaxInt, ev)
+	}
+	return indicators
+}
+
+// add returns a + b, b if a is nil.
+func add(a, b *rlwe.Ciphertext, eval *hefloat.Evaluator) (output *rlwe.Ciphertext) {
+
+	if a == nil {
+		return b
+	}
+	output, err := eval.AddNew(a, b)
+	if err != nil {
+		panic(err)
+	}
+	return output
+}
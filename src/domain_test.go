@@ -0,0 +1,39 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+// TestForwardFlagged runs two tanh nodes on [-2, 2], of reach 2 on the
+// declared ranges of their features, with slots whose pre-activations stay
+// well inside both intervals and slots where one or both leave theirs. The
+// flag of the slots inside must decrypt near 0, and that of the others at
+// least near 1, within the margins documented by Block.OutOfDomain.
+func TestForwardFlagged(t *testing.T) {
+
+	k := newTestKeys(t)
+	m := passThrough([][]float64{{-4, 4}, {-4, 4}})
+	for i := range m.Layers[0].Nodes {
+		m.Layers[0].Nodes[i].Activation = "tanh"
+		m.Layers[0].Nodes[i].Interval = []float64{-2, 2}
+		m.Layers[0].Nodes[i].Degree = 31
+	}
+	features := [][]float64{
+		{0, 1.5, -1.5, 0.3, 2.4, -3.9, 0.5, 3},
+		{0, -1, 1.2, -1.55, 0.2, 1, -3, 3.5},
+	}
+	out := []bool{false, false, false, false, true, true, true, true}
+	_, flag := m.ForwardFlagged(k.encrypt(t, features), k.eval, k.eval_boot, k.params)
+	got := k.decrypt(t, flag, len(out))
+	for i := range got {
+		switch {
+		case Flagged(got[i]) != out[i]:
+			t.Errorf("slot %d (%g, %g): flag %g", i, features[0][i], features[1][i], got[i])
+		case !out[i] && !(math.Abs(got[i]) < 1e-2):
+			t.Errorf("slot %d (%g, %g): flag %g, want 0", i, features[0][i], features[1][i], got[i])
+		case out[i] && !(got[i] > 0.95):
+			t.Errorf("slot %d (%g, %g): flag %g, want at least 1", i, features[0][i], features[1][i], got[i])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// ExpStrategy evaluates c*exp(a*x + b) + d on a wide range as the 2^k-th power
+// of r exp((a*x + b - u)/2^k), with u the largest value of a*x + b on the
+// interval and r = (|c| exp(u))^(1/2^k): the offset b - u is folded in the
+// constant of the inner product and exp(u) in the factor r, so that the
+// reduced polynomial stays below r and its squares below the largest output. The polynomial runs on an interval 2^k times
+// smaller than a*x + b, where a low degree approximates exp to high relative
+// precision. Squaring doubles the relative error k times, while the relative
+// noise of the polynomial grows as exp of the width of the reduced interval;
+// k and the degree minimize their sum within the level budget, the degree
+// never above that of the node.
+type ExpStrategy struct {
+	A, B, C, D float64
+}
+
+// NewExpStrategy returns the ExpStrategy of a node of activation exp.
+func NewExpStrategy(ns NodeSpec) (Strategy, error) {
+	if ns.Activation != "exp" {
+		return nil, fmt.Errorf("exp strategy on activation %q", ns.Activation)
+	}
+	a, b, c, d := ns.Maps()
+	return ExpStrategy{A: a, B: b, C: c, D: d}, nil
+}
+
+func (s ExpStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	var err error
+	k, degree := s.reduction(n.Coefficients_mult, interval, degree, budget)
+	_, u := s.exponents(interval)
+	scale := math.Exp2(float64(k))
+	root := math.Exp((math.Log(math.Abs(s.C)) + u) / scale)
+
+	reduced := n
+	reduced.Activation = func(x float64) float64 { return root * math.Exp((s.A*x+s.B-u)/scale) }
+	reduced.Approximation = GetChebyshevPoly
+	output, depth = PolynomialStrategy{}.Evaluate(reduced, interval, degree, budget, ev)
+
+	for i := 0; i < k; i++ {
+		if output, err = ev.Eval.MulRelinNew(output, output); err != nil {
+			panic(err)
+		}
+		if err = ev.Eval.Rescale(output, output); err != nil {
+			panic(err)
+		}
+	}
+	if s.C < 0 {
+		if err = ev.Eval.Mul(output, -1, output); err != nil {
+			panic(err)
+		}
+	}
+	if err = ev.Eval.Add(output, s.D, output); err != nil {
+		panic(err)
+	}
+	return output, depth + k
+}
+
+func (s ExpStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	k, degree := s.reduction(coefficients_mult, interval, degree, budget)
+	return PolynomialStrategy{}.Depth(coefficients_mult, interval, degree, budget) + k
+}
+
+// exponents returns the range of a*x + b on the interval.
+func (s ExpStrategy) exponents(interval []float64) (lo, hi float64) {
+	lo, hi = s.A*interval[0]+s.B, s.A*interval[1]+s.B
+	return math.Min(lo, hi), math.Max(lo, hi)
+}
+
+// reduction returns the number k of squarings and the degree of the reduced
+// polynomial with the lowest estimated relative error that fit in budget.
+// The degrees tried are of the form 2^j - 1, which use their levels fully.
+// If none fits, it returns the node degree without reduction.
+func (s ExpStrategy) reduction(coefficients_mult []float64, interval []float64, degree int, budget int) (k, reduced int) {
+
+	lo, hi := s.exponents(interval)
+	best := math.Inf(1)
+	k, reduced = 0, degree
+	for i := 0; i < 32; i++ {
+		w := (hi - lo) / math.Exp2(float64(i))
+		noise := PolynomialNoise * math.Exp(w)
+		for j := 1; j <= bits.Len(uint(degree)); j++ {
+			d := 1<<j - 1
+			if d > degree {
+				d = degree
+			}
+			if (PolynomialStrategy{}).Depth(coefficients_mult, interval, d, budget)+i > budget {
+				break
+			}
+			// Chebyshev interpolation error of exp on an interval of width
+			// w, relative to its smallest value.
+			approx := 2 * math.Pow(w/4, float64(d+1)) / math.Gamma(float64(d+2)) * math.Exp(w/2)
+			if e := math.Exp2(float64(i)) * (approx + noise); e < best {
+				best, k, reduced = e, i, d
+			}
+			if approx <= noise {
+				break
+			}
+		}
+	}
+	return k, reduced
+}
@@ -0,0 +1,66 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpReduction(t *testing.T) {
+
+	for _, tc := range []struct {
+		interval  []float64
+		budget    int
+		k, degree int
+		depth     int
+	}{
+		{[]float64{-1, 1}, 10, 2, 7, 5},
+		{[]float64{-1, 1}, 4, 0, 15, 4},
+		{[]float64{-8, 8}, 10, 5, 7, 9},
+		{[]float64{-8, 8}, 6, 1, 15, 6},
+		{[]float64{-8, 8}, 4, 2, 1, 4},
+		{[]float64{-64, 64}, 10, 5, 15, 10},
+		{[]float64{-64, 64}, 6, 4, 1, 6},
+		// Not even degree 1 fits: the node degree, without reduction.
+		{[]float64{-8, 8}, 1, 0, 31, 6},
+	} {
+		s := ExpStrategy{A: 1, C: 1}
+		weights := []float64{1}
+		k, degree := s.reduction(weights, tc.interval, 31, tc.budget)
+		depth := s.Depth(weights, tc.interval, 31, tc.budget)
+		if k != tc.k || degree != tc.degree || depth != tc.depth {
+			t.Errorf("%v within %d levels: k %d, degree %d, depth %d, want %d, %d, %d", tc.interval, tc.budget, k, degree, depth, tc.k, tc.degree, tc.depth)
+		}
+		if k > 0 && depth != (PolynomialStrategy{}).Depth(weights, tc.interval, degree, tc.budget)+k {
+			t.Errorf("%v within %d levels: depth %d is not that of the polynomial and %d squarings", tc.interval, tc.budget, depth, k)
+		}
+	}
+}
+
+func TestExpStrategy(t *testing.T) {
+
+	k := newTestKeys(t)
+	ns := BreastCancerModel().Layers[2].Nodes[0]
+	if ns.Activation != "exp" {
+		t.Fatalf("node of activation %s", ns.Activation)
+	}
+	ns.Strategy = "exp"
+
+	// The pre-activation is 2.93 x2 up to the other features, spanning the
+	// interval [-8, 8] and reaching both ends.
+	x2 := []float64{-8 / 2.93, -2, -0.5, 0, 0.3, 1, 2, 8 / 2.93}
+	features := [][]float64{make([]float64, len(x2)), make([]float64, len(x2)), x2, make([]float64, len(x2))}
+	for i := range x2 {
+		features[0][i] = 0.1 * float64(i%3)
+		features[1][i] = -0.05 * float64(i%2)
+	}
+	got, depth := k.evaluateNode(t, ns, features)
+	t.Logf("depth %d", depth)
+	f := ns.Function()
+	for i := range got {
+		x := []float64{features[0][i], features[1][i], features[2][i], features[3][i]}
+		want := f(ns.Preactivation(x))
+		if math.Abs(got[i]-want) > 1e-4*want {
+			t.Errorf("slot %d: exp(%g) = %g, want %g", i, ns.Preactivation(x), got[i], want)
+		}
+	}
+}
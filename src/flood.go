@@ -0,0 +1,119 @@
+package src
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// FloodingNoise returns, for each output of the model, the standard deviation,
+// in message units, of the noise Flood adds to its ciphertext for a
+// statistical security of bits against key recovery from its decryptions:
+// Flooding of the CKKS error bound of the output. The bound is that of
+// ErrorBudget under noise, on the input bounds pre of the activations, as
+// those of DeclaredBounds; it counts the encryption and the evaluation but not
+// the approximation, which does not depend on the key.
+func (m Model) FloodingNoise(noise Noise, pre [][][]float64, bits int) (sigma []float64) {
+	b := m.ErrorBudget(noise, pre)
+	sigma = make([]float64, len(b.Inputs))
+	for j := range sigma {
+		e := b.Inputs[j]
+		for l := range b.Noise {
+			for i := range b.Noise[l] {
+				e += b.Noise[l][i] * b.Gain[l][i][j]
+			}
+		}
+		sigma[j] = Flooding(e, bits)
+	}
+	return sigma
+}
+
+// Flooding returns 2^(bits/2) times the error bound e, the standard deviation
+// of flooding noise for a statistical security of bits, after the Rényi
+// divergence argument of Li, Micciancio, Schultz and Sorrell. The labels,
+// one-hot vectors and flags end on a bootstrapping, whose error bounds theirs.
+// Each further decryption of the same ciphertext that is shared spends about
+// one bit of security per doubling of their number.
+func Flooding(e float64, bits int) float64 {
+	return math.Exp2(float64(bits)/2) * e
+}
+
+// Flood adds to ct, in place, fresh discrete gaussian noise of standard
+// deviation sigma on the decoded value of every slot, from FloodingPoly, so
+// that it drowns the noise the evaluation left in the coefficients.
+func Flood(ct *rlwe.Ciphertext, sigma float64, params hefloat.Parameters) error {
+	e, err := FloodingPoly(ct, sigma, params)
+	if err != nil {
+		return err
+	}
+	params.RingQ().AtLevel(ct.Level()).Add(ct.Value[0], e, ct.Value[0])
+	return nil
+}
+
+// CheckFlooding fails if the tail of noise of standard deviation sigma on the
+// decoded values of ct, at 6 sigma, reaches half the modulus of ct at its
+// scale, where the decryption wraps around.
+func CheckFlooding(ct *rlwe.Ciphertext, sigma float64, params hefloat.Parameters) error {
+	logQ := 0.0
+	for _, q := range params.Q()[:ct.Level()+1] {
+		logQ += math.Log2(float64(q))
+	}
+	if tail := math.Log2(6 * sigma * ct.Scale.Float64()); tail >= logQ-1 {
+		return fmt.Errorf("flooding deviation %.3e: its 6 sigma tail takes %.1f bits of the %.1f bits of the modulus at level %d", sigma, tail, logQ, ct.Level())
+	}
+	return nil
+}
+
+// FloodingPoly returns discrete gaussian noise of standard deviation sigma on
+// the decoded value of every slot of ct: sigma D / sqrt(N/2) on each
+// coefficient, for the scale D of ct and the ring degree N, at the level and
+// in the domain of ct. It fails as CheckFlooding.
+func FloodingPoly(ct *rlwe.Ciphertext, sigma float64, params hefloat.Parameters) (e ring.Poly, err error) {
+
+	if err = CheckFlooding(ct, sigma, params); err != nil {
+		return e, err
+	}
+
+	// The coefficients are sampled as integers and reduced modulo each
+	// prime, as ring.Sampler leaves those larger than a prime unreduced.
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	ringQ := params.RingQ().AtLevel(ct.Level())
+	deviation := sigma * ct.Scale.Float64() / math.Sqrt(float64(params.N())/2)
+	coeffs := make([]*big.Int, ringQ.N())
+	buffer := make([]byte, 16)
+	for k := range coeffs {
+		v := math.Inf(1)
+		for math.Abs(v) > 6*deviation {
+			if _, err = prng.Read(buffer); err != nil {
+				panic(err)
+			}
+			// Box-Muller, on two uniform values of (0, 1] and [0, 1).
+			u := float64(binary.LittleEndian.Uint64(buffer[:8])>>11+1) / (1 << 53)
+			w := float64(binary.LittleEndian.Uint64(buffer[8:])>>11) / (1 << 53)
+			v = math.Round(deviation * math.Sqrt(-2*math.Log(u)) * math.Cos(2*math.Pi*w))
+		}
+		coeffs[k], _ = big.NewFloat(v).Int(nil)
+	}
+	e = ringQ.NewPoly()
+	ringQ.SetCoefficientsBigint(coeffs, e)
+	if ct.IsNTT {
+		ringQ.NTT(e, e)
+	}
+	return e, nil
+}
+
+// Round returns x rounded to digits decimal digits, as the client publishes a
+// decrypted value whose last digits are flooding noise.
+func Round(x float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(x*p) / p
+}
@@ -0,0 +1,46 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlood(t *testing.T) {
+
+	k := newTestKeys(t)
+	slots := k.params.MaxSlots()
+	for _, tc := range []struct {
+		name  string
+		sigma float64
+		level int
+		fails bool
+	}{
+		{"small", 1e-3, k.params.MaxLevel(), false},
+		// Coefficients of about 2^60, beyond every prime of the modulus.
+		{"large", 1e3, k.params.MaxLevel(), false},
+		{"last level", 1e2, 0, false},
+		{"wrapping", 1e4, 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ct := k.encrypt(t, [][]float64{make([]float64, slots)})[0]
+			k.eval.DropLevel(ct, ct.Level()-tc.level)
+			err := Flood(ct, tc.sigma, k.params)
+			if (err != nil) != tc.fails {
+				t.Fatalf("error %v", err)
+			}
+			if tc.fails {
+				return
+			}
+			values := k.decrypt(t, ct, slots)
+			sum, largest := 0.0, 0.0
+			for _, v := range values {
+				sum += v * v
+				largest = math.Max(largest, math.Abs(v))
+			}
+			deviation := math.Sqrt(sum / float64(slots))
+			if math.Abs(deviation/tc.sigma-1) > 0.1 || largest > 8*tc.sigma {
+				t.Errorf("deviation %g and largest value %g for a sigma of %g", deviation, largest, tc.sigma)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package src
+
+// FoldWeights returns a copy of m in which as many edges after the first
+// layer as possible cost no level of their own. The nodes after the first
+// layer are mapped so that their interval becomes [-1, 1], which makes their
+// change of basis the identity; then the weight most of the edges out of a
+// producer carry is folded into the output map of the producer, that is into
+// the coefficients of its polynomial, and divided out of all its edges, so
+// that those edges get weight 1. Producers are never duplicated: a copy per
+// weight would cost a polynomial evaluation, and a bootstrapping if its layer
+// is bootstrapped, for each level it saves. The model computes the same
+// function, up to rounding.
+func (m Model) FoldWeights() Model {
+
+	m = m.Clone()
+	for l := 1; l < len(m.Layers); l++ {
+		for n := range m.Layers[l].Nodes {
+			ns := &m.Layers[l].Nodes[n]
+			lo, hi := ns.Interval[0], ns.Interval[1]
+			ns.mapInput(2/(hi-lo), -(hi+lo)/(hi-lo))
+		}
+
+		// folded[i] is the weight of the most frequent nonzero edge out of
+		// node i of the previous layer, the first one on a tie.
+		previous := &m.Layers[l-1]
+		counts := make([]map[float64]int, len(previous.Nodes))
+		folded := make([]float64, len(previous.Nodes))
+		for _, ns := range m.Layers[l].Nodes {
+			for k, i := range ns.Input {
+				w := ns.Coefficients_mult[k]
+				if w == 0 {
+					continue
+				}
+				if counts[i] == nil {
+					counts[i] = make(map[float64]int)
+				}
+				counts[i][w]++
+				if folded[i] == 0 || counts[i][w] > counts[i][folded[i]] {
+					folded[i] = w
+				}
+			}
+		}
+
+		for i := range previous.Nodes {
+			if folded[i] != 0 {
+				previous.Nodes[i].scaleOutput(folded[i])
+			}
+		}
+		for n := range m.Layers[l].Nodes {
+			ns := &m.Layers[l].Nodes[n]
+			for k, i := range ns.Input {
+				if ns.Coefficients_mult[k] != 0 {
+					ns.Coefficients_mult[k] /= folded[i]
+				}
+			}
+		}
+	}
+	return m
+}
+
+// scaleOutput multiplies the output map of the node by w.
+func (ns *NodeSpec) scaleOutput(w float64) {
+	_, _, c, d := ns.Maps()
+	ns.Activation_out = []float64{w * c, w * d}
+}
@@ -0,0 +1,198 @@
+package src
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+)
+
+// InverseDegree is the largest degree of the initial guess of Inverse and
+// Divide.
+var InverseDegree = 31
+
+// InverseStrategy evaluates c/(a*x + b) + d on a positive range of
+// v = a*x + b by Goldschmidt iterations from a polynomial initial guess y_0 of
+// c/v on the range: with e_0 = 1 - v y_0/c,
+//
+//	y_(k+1) = y_k (1 + e_k), e_(k+1) = e_k^2,
+//
+// so that the relative error e_0^(2^k) squares at each iteration, for one
+// level. The factor 1/c is folded in the weights of v. The initial degree and
+// the number of iterations are chosen on a plaintext simulation, with a
+// single polynomial of the node degree as one more candidate.
+type InverseStrategy struct {
+	A, B, C, D float64
+}
+
+// NewInverseStrategy returns the InverseStrategy of a node of activation
+// inverse whose interval maps to positive inputs of the activation.
+func NewInverseStrategy(ns NodeSpec) (Strategy, error) {
+	if ns.Activation != "inverse" {
+		return nil, fmt.Errorf("inverse strategy on activation %q", ns.Activation)
+	}
+	a, b, c, d := ns.Maps()
+	s := InverseStrategy{A: a, B: b, C: c, D: d}
+	if lo, _ := s.inputs(ns.Interval); lo <= 0 {
+		return nil, fmt.Errorf("inverse strategy on %v, which reaches %g", ns.Interval, lo)
+	}
+	if c == 0 {
+		return nil, fmt.Errorf("inverse strategy with a zero output map")
+	}
+	return s, nil
+}
+
+func (s InverseStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	var err error
+	level := n.Input[0].Level()
+	degree, iterations := s.plan(n.Coefficients_mult, interval, degree, budget)
+	if iterations == 0 {
+		return PolynomialStrategy{}.Evaluate(n, interval, degree, budget, ev)
+	}
+
+	guess := n
+	guess.Activation = func(x float64) float64 { return s.C / (s.A*x + s.B) }
+	guess.Approximation = GetChebyshevPoly
+	y, _ := PolynomialStrategy{}.Evaluate(guess, interval, degree, budget, ev)
+
+	// v/c
+	coefficients_mult := make([]float64, len(n.Coefficients_mult))
+	for i, w := range n.Coefficients_mult {
+		coefficients_mult[i] = s.A * w / s.C
+	}
+	v := Innerproduct(coefficients_mult, (s.A*n.Coefficient_add+s.B)/s.C, n.Input, ev.Eval)
+
+	mul := func(x, y *rlwe.Ciphertext) *rlwe.Ciphertext {
+		z, err := ev.Eval.MulRelinNew(x, y)
+		if err != nil {
+			panic(err)
+		}
+		if err = ev.Eval.Rescale(z, z); err != nil {
+			panic(err)
+		}
+		return z
+	}
+	// 1 + e in place of e.
+	e := mul(v, y)
+	if err = ev.Eval.Mul(e, -1, e); err != nil {
+		panic(err)
+	}
+	if err = ev.Eval.Add(e, 2, e); err != nil {
+		panic(err)
+	}
+	for i := 0; i < iterations; i++ {
+		y = mul(y, e)
+		if i == iterations-1 {
+			break
+		}
+		// 1 + e^2 from 1 + e.
+		if err = ev.Eval.Add(e, -1, e); err != nil {
+			panic(err)
+		}
+		e = mul(e, e)
+		if err = ev.Eval.Add(e, 1, e); err != nil {
+			panic(err)
+		}
+	}
+
+	output = y
+	if err = ev.Eval.Add(output, s.D, output); err != nil {
+		panic(err)
+	}
+	return output, level - output.Level()
+}
+
+func (s InverseStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	degree, iterations := s.plan(coefficients_mult, interval, degree, budget)
+	return s.depth(coefficients_mult, interval, degree, iterations)
+}
+
+// depth returns the levels consumed with the given initial degree and
+// number of iterations, none meaning a single polynomial of that degree.
+func (s InverseStrategy) depth(coefficients_mult []float64, interval []float64, degree, iterations int) int {
+	depth := PolynomialStrategy{}.Depth(coefficients_mult, interval, degree, math.MaxInt)
+	if iterations == 0 {
+		return depth
+	}
+	return depth + 1 + iterations
+}
+
+// inputs returns the range of a*x + b on the interval.
+func (s InverseStrategy) inputs(interval []float64) (lo, hi float64) {
+	lo, hi = s.A*interval[0]+s.B, s.A*interval[1]+s.B
+	return math.Min(lo, hi), math.Max(lo, hi)
+}
+
+// plan returns the initial degree and the number of iterations to use, by
+// running them on a grid of the range.
+func (s InverseStrategy) plan(coefficients_mult []float64, interval []float64, degree int, budget int) (int, int) {
+
+	lo, hi := s.inputs(interval)
+	f := func(v float64) float64 { return 1 / v }
+	points := grid(lo, hi, 1024)
+	largest := 1 / lo
+
+	var plans [][2]int
+	var depths []int
+	var errs []float64
+	add := func(d, iterations int, y func(v float64) float64) {
+		e := 0.0
+		for _, v := range points {
+			e = math.Max(e, math.Abs(y(v)-f(v)))
+		}
+		if math.IsNaN(e) {
+			e = math.Inf(1)
+		}
+		plans = append(plans, [2]int{d, iterations})
+		depths = append(depths, s.depth(coefficients_mult, interval, d, iterations))
+		errs = append(errs, e)
+	}
+
+	add(degree, 0, interpolant(lo, hi, degree, f))
+	for _, d := range degrees(degree) {
+		guess := interpolant(lo, hi, d, f)
+		for iterations := 1; iterations <= 8; iterations++ {
+			add(d, iterations, func(v float64) float64 {
+				y := guess(v)
+				e := 1 - v*y
+				for i := 0; i < iterations; i++ {
+					y, e = y*(1+e), e*e
+				}
+				return y
+			})
+		}
+	}
+
+	best := choose(depths, errs, PolynomialNoise*largest, budget)
+	if best < 0 {
+		return degree, 0
+	}
+	return plans[best][0], plans[best][1]
+}
+
+// Inverse returns 1/ct for values of ct in the positive interval, consuming
+// at most budget levels.
+func Inverse(ct *rlwe.Ciphertext, interval []float64, budget int, eval *hefloat.Evaluator, params hefloat.Parameters) *rlwe.Ciphertext {
+	n := Node{
+		Coefficients_mult: []float64{1},
+		Activation:        func(x float64) float64 { return 1 / x },
+		Input:             []*rlwe.Ciphertext{ct},
+	}
+	output, _ := InverseStrategy{A: 1, C: 1}.Evaluate(n, interval, InverseDegree, budget, NewEvaluation(eval, params))
+	return output
+}
+
+// Divide returns num/den for values of den in the positive interval,
+// consuming at most budget levels.
+func Divide(num, den *rlwe.Ciphertext, interval []float64, budget int, eval *hefloat.Evaluator, params hefloat.Parameters) *rlwe.Ciphertext {
+	output, err := eval.MulRelinNew(num, Inverse(den, interval, budget-1, eval, params))
+	if err != nil {
+		panic(err)
+	}
+	if err = eval.Rescale(output, output); err != nil {
+		panic(err)
+	}
+	return output
+}
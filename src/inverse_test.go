@@ -0,0 +1,59 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// TestInverse evaluates 1/x and a/b on points of positive intervals,
+// including both ends, within budgets of levels, to a precision relative to
+// their largest value.
+func TestInverse(t *testing.T) {
+
+	k := newTestKeys(t)
+	for _, tc := range []struct {
+		interval []float64
+		budget   int
+	}{
+		{[]float64{0.5, 4}, 10},
+		{[]float64{0.5, 4}, 7},
+		{[]float64{0.05, 1}, 10},
+		{[]float64{1, 100}, 10},
+	} {
+		lo, hi := tc.interval[0], tc.interval[1]
+		den := append([]float64{lo, hi}, grid(lo, hi, 14)...)
+		num := make([]float64, len(den))
+		for i := range num {
+			num[i] = math.Sin(float64(i)) * 2
+		}
+		cts := k.encrypt(t, [][]float64{num, den})
+		level := cts[1].Level()
+
+		inverse := Inverse(cts[1], tc.interval, tc.budget, k.eval, k.params)
+		quotient := Divide(cts[0], cts[1], tc.interval, tc.budget, k.eval, k.params)
+		for _, c := range []struct {
+			name string
+			ct   *rlwe.Ciphertext
+			want func(i int) float64
+		}{
+			{"1/x", inverse, func(i int) float64 { return 1 / den[i] }},
+			{"a/b", quotient, func(i int) float64 { return num[i] / den[i] }},
+		} {
+			if depth := level - c.ct.Level(); depth > tc.budget {
+				t.Errorf("%s on %v: depth %d beyond %d levels", c.name, tc.interval, depth, tc.budget)
+			}
+			got := k.decrypt(t, c.ct, len(den))
+			largest := 0.0
+			for i := range got {
+				largest = math.Max(largest, math.Abs(c.want(i)))
+			}
+			for i := range got {
+				if e := math.Abs(got[i] - c.want(i)); !(e < 1e-6*largest) {
+					t.Errorf("%s on %v within %d levels: %g at %g/%g, want %g", c.name, tc.interval, tc.budget, got[i], num[i], den[i], c.want(i))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package src
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v5/utils/bignum"
+)
+
+// LeastSquaresGrowth bounds the error of a least-squares fit over the whole
+// interval, as a multiple of the error of the Chebyshev interpolant.
+var LeastSquaresGrowth = 10.0
+
+// GetLeastSquaresPoly returns the polynomial of the given degree, in the
+// Chebyshev basis of [K_left, K_right], minimizing the mean squared error on
+// the samples, which follow the distribution of the inputs. Outside of the
+// data the polynomial is held close to f64 by a small weight on a Chebyshev
+// grid of the interval, raised until the error on the whole interval is at
+// most LeastSquaresGrowth times that of the Chebyshev interpolant. Samples
+// outside of the interval are ignored.
+func GetLeastSquaresPoly(K_left, K_right float64, degree int, f64 func(x float64) (y float64), samples []float64) bignum.Polynomial {
+
+	t := func(x float64) float64 { return (2*x - K_left - K_right) / (K_right - K_left) }
+	x := func(t float64) float64 { return K_left + (t+1)*(K_right-K_left)/2 }
+
+	var data []float64
+	for _, s := range samples {
+		if s >= K_left && s <= K_right {
+			data = append(data, t(s))
+		}
+	}
+	grid := make([]float64, 4*(degree+1))
+	for j := range grid {
+		grid[j] = -math.Cos(math.Pi * (float64(j) + 0.5) / float64(len(grid)))
+	}
+
+	interpolant := GetChebyshevPoly(K_left, K_right, degree, f64)
+	coeffs := make([]float64, degree+1)
+	for i, c := range interpolant.Coeffs {
+		coeffs[i], _ = c.Real().Float64()
+	}
+	worst := func(coeffs []float64) (e float64) {
+		for j := 0; j <= 4096; j++ {
+			t := -1 + float64(j)/2048
+			e = math.Max(e, math.Abs(f64(x(t))-chebyshev(coeffs, t)))
+		}
+		return e
+	}
+	bound := LeastSquaresGrowth * worst(coeffs)
+	if len(data) == 0 {
+		return interpolant
+	}
+
+	// The data carries a weight 1 - epsilon, the grid epsilon.
+	for _, epsilon := range []float64{1e-4, 1e-3, 1e-2, 1e-1, 0.5} {
+		rows := make([][]float64, 0, len(data)+len(grid))
+		row := func(t, weight float64) {
+			r := make([]float64, degree+2)
+			chebyshevBasis(t, r[:degree+1])
+			w := math.Sqrt(weight)
+			for k := range r[:degree+1] {
+				r[k] *= w
+			}
+			r[degree+1] = w * f64(x(t))
+			rows = append(rows, r)
+		}
+		for _, t := range data {
+			row(t, (1-epsilon)/float64(len(data)))
+		}
+		for _, t := range grid {
+			row(t, epsilon/float64(len(grid)))
+		}
+
+		fit, err := leastSquares(rows, degree+1)
+		if err == nil && worst(fit) <= bound {
+			coeffs = fit
+			break
+		}
+	}
+
+	return bignum.NewPolynomial(bignum.Chebyshev, coeffs, [2]float64{K_left, K_right})
+}
+
+// Calibrate returns a copy of m whose nodes use the given approximation and
+// keep at most num quantiles of the inputs of their activation on the rows,
+// given in the order of m.Features.
+func (m Model) Calibrate(rows [][]float64, num int, approximation string) Model {
+
+	m = m.Clone()
+	values := make([][][]float64, len(m.Layers))
+	for l, layer := range m.Layers {
+		values[l] = make([][]float64, len(layer.Nodes))
+	}
+	for _, x := range rows {
+		for l, pre := range m.Preactivations(x) {
+			for i, v := range pre {
+				values[l][i] = append(values[l][i], v)
+			}
+		}
+	}
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			m.Layers[l].Nodes[i].Samples = Quantiles(values[l][i], num)
+			m.Layers[l].Nodes[i].Approximation = approximation
+		}
+	}
+	return m
+}
+
+// SampleError returns the root mean squared error of the approximation of
+// the node on the samples that fall in its interval.
+func (ns NodeSpec) SampleError(samples []float64) float64 {
+
+	f := ns.Function()
+	lo, hi := ns.Interval[0], ns.Interval[1]
+	poly := ns.Approximator()(lo, hi, ns.Degree, f)
+	coeffs := make([]float64, len(poly.Coeffs))
+	for i, c := range poly.Coeffs {
+		coeffs[i], _ = c.Real().Float64()
+	}
+
+	sum, n := 0.0, 0
+	for _, x := range samples {
+		if x >= lo && x <= hi {
+			e := f(x) - chebyshev(coeffs, (2*x-lo-hi)/(hi-lo))
+			sum, n = sum+e*e, n+1
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// Quantiles returns at most num values following the distribution of values:
+// all of them if there are few enough, else evenly spaced quantiles from the
+// smallest to the largest, or the median for a single one.
+func Quantiles(values []float64, num int) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	switch {
+	case len(sorted) <= num:
+		return sorted
+	case num <= 0:
+		return nil
+	case num == 1:
+		middle := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return []float64{(sorted[middle-1] + sorted[middle]) / 2}
+		}
+		return []float64{sorted[middle]}
+	}
+	quantiles := make([]float64, num)
+	for i := range quantiles {
+		quantiles[i] = sorted[i*(len(sorted)-1)/(num-1)]
+	}
+	return quantiles
+}
+
+// leastSquares solves the overdetermined system given as rows of an augmented
+// matrix with n unknowns in the least-squares sense, by Householder QR.
+func leastSquares(rows [][]float64, n int) ([]float64, error) {
+	m := len(rows)
+	if m < n {
+		return nil, errSingular
+	}
+	for col := 0; col < n; col++ {
+		norm := 0.0
+		for r := col; r < m; r++ {
+			norm += rows[r][col] * rows[r][col]
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			continue
+		}
+		if rows[col][col] > 0 {
+			norm = -norm
+		}
+		// Householder reflection along v = x - norm e_1.
+		v := make([]float64, m-col)
+		for r := col; r < m; r++ {
+			v[r-col] = rows[r][col]
+		}
+		v[0] -= norm
+		vv := 0.0
+		for _, e := range v {
+			vv += e * e
+		}
+		if vv == 0 {
+			continue
+		}
+		for k := col; k <= n; k++ {
+			dot := 0.0
+			for r := col; r < m; r++ {
+				dot += v[r-col] * rows[r][k]
+			}
+			for r := col; r < m; r++ {
+				rows[r][k] -= 2 * dot / vv * v[r-col]
+			}
+		}
+	}
+	return solve(triangular(rows, n))
+}
+
+// triangular returns the square upper part of the reduced rows, augmented.
+func triangular(rows [][]float64, n int) [][]float64 {
+	system := make([][]float64, n)
+	for r := range system {
+		system[r] = append([]float64(nil), rows[r][:n+1]...)
+	}
+	return system
+}
@@ -0,0 +1,79 @@
+package src
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestQuantiles(t *testing.T) {
+
+	values := []float64{5, 1, 4, 2, 3, 9, 7, 8, 6}
+	for _, tc := range []struct {
+		values []float64
+		num    int
+		want   []float64
+	}{
+		{values, 20, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{values, 3, []float64{1, 5, 9}},
+		{values, 2, []float64{1, 9}},
+		{values, 1, []float64{5}},
+		{values[:4], 1, []float64{3}},
+		{values, 0, nil},
+		{nil, 1, nil},
+	} {
+		if got := Quantiles(tc.values, tc.num); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%d quantiles of %v: %v, want %v", tc.num, tc.values, got, tc.want)
+		}
+	}
+}
+
+// TestGetLeastSquaresPoly fits activations to samples of a normal
+// distribution narrower than the interval: the fit must have a lower mean
+// squared error than the interpolant on the samples, and stay within
+// LeastSquaresGrowth of its error on the whole interval.
+func TestGetLeastSquaresPoly(t *testing.T) {
+
+	for _, tc := range []struct {
+		activation  string
+		lo, hi      float64
+		degree      int
+		mean, sigma float64
+	}{
+		{"tanh", -8, 8, 15, 0.5, 1},
+		{"sin", -16, 16, 31, -2, 3},
+		{"sqrt", 0.1, 8, 15, 1.5, 0.5},
+		{"log", 0.1, 6, 31, 3, 1},
+	} {
+		f := Activations[tc.activation]
+		samples := make([]float64, 1000)
+		for i := range samples {
+			samples[i] = tc.mean + tc.sigma*math.Sqrt2*math.Erfinv(2*(float64(i)+0.5)/float64(len(samples))-1)
+		}
+		rms := func(p func(float64) float64) float64 {
+			sum, n := 0.0, 0
+			for _, x := range samples {
+				if x >= tc.lo && x <= tc.hi {
+					sum, n = sum+math.Pow(f(x)-p(x), 2), n+1
+				}
+			}
+			return math.Sqrt(sum / float64(n))
+		}
+		fit := GetLeastSquaresPoly(tc.lo, tc.hi, tc.degree, f, samples)
+		coeffs := make([]float64, len(fit.Coeffs))
+		for i, c := range fit.Coeffs {
+			coeffs[i], _ = c.Real().Float64()
+		}
+		leastSquares := rms(func(x float64) float64 { return chebyshev(coeffs, (2*x-tc.lo-tc.hi)/(tc.hi-tc.lo)) })
+		chebyshev := rms(interpolant(tc.lo, tc.hi, tc.degree, f))
+		worst := weightedError(fit, tc.lo, tc.hi, f, nil)
+		bound := LeastSquaresGrowth * weightedError(GetChebyshevPoly(tc.lo, tc.hi, tc.degree, f), tc.lo, tc.hi, f, nil)
+		t.Logf("%s on [%g, %g] at degree %d: rms %.3e against %.3e, max %.3e within %.3e", tc.activation, tc.lo, tc.hi, tc.degree, leastSquares, chebyshev, worst, bound)
+		if !(leastSquares <= chebyshev) {
+			t.Errorf("%s on [%g, %g] at degree %d: rms %g on the samples above %g of the interpolant", tc.activation, tc.lo, tc.hi, tc.degree, leastSquares, chebyshev)
+		}
+		if !(worst <= bound*1.01) {
+			t.Errorf("%s on [%g, %g] at degree %d: error %g on the interval beyond %g", tc.activation, tc.lo, tc.hi, tc.degree, worst, bound)
+		}
+	}
+}
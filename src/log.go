@@ -0,0 +1,138 @@
+package src
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// LogStrategy evaluates c*log(a*x + b) + d on a positive range [lo, hi] of
+// v = a*x + b, reduced around its geometric center m = sqrt(lo*hi):
+//
+//	log(v) = log(m) + 2 atanh(z), z = (v - m)/(v + m),
+//
+// where z spans the symmetric interval [-r, r], r = (sqrt(hi/lo) - 1)/(sqrt(hi/lo) + 1).
+// A single polynomial for log must follow its singularity at 0, at a
+// distance lo of the range. The pole of z at -m and the singularities of
+// atanh at -1 and 1 are much farther from their ranges, relative to their
+// widths, so that two polynomials of low degree, the second on z/r in
+// [-1, 1], reach a higher precision. The degrees are chosen on a plaintext
+// simulation, with a single polynomial of the node degree as one more
+// candidate.
+type LogStrategy struct {
+	A, B, C, D float64
+}
+
+// NewLogStrategy returns the LogStrategy of a node of activation log whose
+// interval maps to positive inputs of the activation.
+func NewLogStrategy(ns NodeSpec) (Strategy, error) {
+	if ns.Activation != "log" {
+		return nil, fmt.Errorf("log strategy on activation %q", ns.Activation)
+	}
+	a, b, c, d := ns.Maps()
+	s := LogStrategy{A: a, B: b, C: c, D: d}
+	if lo, _ := s.inputs(ns.Interval); lo <= 0 {
+		return nil, fmt.Errorf("log strategy on %v, which reaches %g", ns.Interval, lo)
+	}
+	return s, nil
+}
+
+func (s LogStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	inner, outer := s.plan(n.Coefficients_mult, interval, degree, budget)
+	if outer == 0 {
+		return PolynomialStrategy{}.Evaluate(n, interval, inner, budget, ev)
+	}
+	z, g := s.reduction(interval)
+
+	first := n
+	first.Activation = func(x float64) float64 { return z(s.A*x + s.B) }
+	first.Approximation = GetChebyshevPoly
+	t, depth := PolynomialStrategy{}.Evaluate(first, interval, inner, budget, ev)
+
+	second := Node{
+		Coefficients_mult: []float64{1},
+		Activation:        func(t float64) float64 { return s.C*g(t) + s.D },
+		Input:             []*rlwe.Ciphertext{t},
+		Approximation:     GetChebyshevPoly,
+	}
+	output, more := PolynomialStrategy{}.Evaluate(second, []float64{-1, 1}, outer, budget-depth, ev)
+	return output, depth + more
+}
+
+func (s LogStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	inner, outer := s.plan(coefficients_mult, interval, degree, budget)
+	return s.depth(coefficients_mult, interval, inner, outer)
+}
+
+// depth returns the levels consumed by polynomials of degrees inner and
+// outer, a single polynomial of degree inner if outer is 0.
+func (s LogStrategy) depth(coefficients_mult []float64, interval []float64, inner, outer int) int {
+	depth := PolynomialStrategy{}.Depth(coefficients_mult, interval, inner, math.MaxInt)
+	if outer > 0 {
+		depth += PolynomialStrategy{}.Depth([]float64{1}, []float64{-1, 1}, outer, math.MaxInt)
+	}
+	return depth
+}
+
+// inputs returns the range of a*x + b on the interval.
+func (s LogStrategy) inputs(interval []float64) (lo, hi float64) {
+	lo, hi = s.A*interval[0]+s.B, s.A*interval[1]+s.B
+	return math.Min(lo, hi), math.Max(lo, hi)
+}
+
+// reduction returns the map from v to z/r, in [-1, 1] on the range, and the
+// map from z/r to log(v).
+func (s LogStrategy) reduction(interval []float64) (z func(v float64) float64, g func(t float64) float64) {
+	lo, hi := s.inputs(interval)
+	m := math.Sqrt(lo * hi)
+	r := (hi - m) / (hi + m)
+	z = func(v float64) float64 { return (v - m) / (v + m) / r }
+	g = func(t float64) float64 { return math.Log(m) + 2*math.Atanh(r*t) }
+	return z, g
+}
+
+// plan returns the degrees of the two polynomials, by running them on a grid
+// of the range.
+func (s LogStrategy) plan(coefficients_mult []float64, interval []float64, degree int, budget int) (inner, outer int) {
+
+	lo, hi := s.inputs(interval)
+	points := grid(lo, hi, 1024)
+	largest := 0.0
+	for _, v := range points {
+		largest = math.Max(largest, math.Abs(math.Log(v)))
+	}
+
+	var plans [][2]int
+	var depths []int
+	var errs []float64
+	add := func(inner, outer int, y func(v float64) float64) {
+		e := 0.0
+		for _, v := range points {
+			e = math.Max(e, math.Abs(y(v)-math.Log(v)))
+		}
+		if math.IsNaN(e) {
+			e = math.Inf(1)
+		}
+		plans = append(plans, [2]int{inner, outer})
+		depths = append(depths, s.depth(coefficients_mult, interval, inner, outer))
+		errs = append(errs, e)
+	}
+
+	add(degree, 0, interpolant(lo, hi, degree, math.Log))
+	z, g := s.reduction(interval)
+	for _, inner := range degrees(degree) {
+		p := interpolant(lo, hi, inner, z)
+		for _, outer := range degrees(degree) {
+			q := interpolant(-1, 1, outer, g)
+			add(inner, outer, func(v float64) float64 { return q(p(v)) })
+		}
+	}
+
+	best := choose(depths, errs, PolynomialNoise*largest, budget)
+	if best < 0 {
+		return degree, 0
+	}
+	return plans[best][0], plans[best][1]
+}
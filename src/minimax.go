@@ -0,0 +1,184 @@
+package src
+
+import (
+	"errors"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/utils/bignum"
+)
+
+// Approximation computes a polynomial approximating f64 on [K_left, K_right],
+// of the given degree, in the Chebyshev basis of that interval.
+type Approximation func(K_left, K_right float64, degree int, f64 func(x float64) (y float64)) bignum.Polynomial
+
+// Approximations maps the approximation names used in model files to their
+// implementation; the empty name is Chebyshev interpolation. A node may also
+// name "leastsquares", which needs its samples, see NodeSpec.Approximator.
+var Approximations = map[string]Approximation{
+	"":          GetChebyshevPoly,
+	"chebyshev": GetChebyshevPoly,
+	"minimax": func(a, b float64, degree int, f func(float64) float64) bignum.Polynomial {
+		return GetMinimaxPoly(a, b, degree, f, nil)
+	},
+	"minimax-relative": func(a, b float64, degree int, f func(float64) float64) bignum.Polynomial {
+		return GetMinimaxPoly(a, b, degree, f, RelativeWeight(a, b, f))
+	},
+}
+
+// GetMinimaxPoly returns the polynomial of the given degree minimizing
+// max |weight(x) (f64(x) - p(x))| on [K_left, K_right], by the Remez exchange
+// algorithm on a fine grid. A nil weight is 1. The result is never worse than
+// the Chebyshev interpolant of the same degree, which is returned instead if
+// the iterations fail to improve on it, as may happen for functions that are
+// not smooth.
+func GetMinimaxPoly(K_left, K_right float64, degree int, f64 func(x float64) (y float64), weight func(x float64) float64) bignum.Polynomial {
+
+	if weight == nil {
+		weight = func(float64) float64 { return 1 }
+	}
+	x := func(t float64) float64 { return K_left + (t+1)*(K_right-K_left)/2 }
+
+	// Grid of the interval, denser near the ends like the Chebyshev nodes.
+	num := max(64*(degree+2), 4096)
+	grid := make([]float64, num)
+	for j := range grid {
+		grid[j] = -math.Cos(math.Pi * float64(j) / float64(num-1))
+	}
+	errors := func(coeffs []float64) (e []float64, worst float64) {
+		e = make([]float64, num)
+		for j, t := range grid {
+			e[j] = weight(x(t)) * (f64(x(t)) - chebyshev(coeffs, t))
+			worst = math.Max(worst, math.Abs(e[j]))
+		}
+		return e, worst
+	}
+
+	interpolant := GetChebyshevPoly(K_left, K_right, degree, f64)
+	best := make([]float64, degree+1)
+	for i, c := range interpolant.Coeffs {
+		best[i], _ = c.Real().Float64()
+	}
+	_, bestErr := errors(best)
+
+	// Initial reference: the extrema of T_{degree+1}.
+	reference := make([]float64, degree+2)
+	for i := range reference {
+		reference[i] = -math.Cos(math.Pi * float64(i) / float64(degree+1))
+	}
+
+	for iteration := 0; iteration < 64; iteration++ {
+
+		// Solves sum c_k T_k(t_i) + (-1)^i E / weight(t_i) = f(t_i).
+		system := make([][]float64, degree+2)
+		for i, t := range reference {
+			system[i] = make([]float64, degree+3)
+			chebyshevBasis(t, system[i][:degree+1])
+			system[i][degree+1] = math.Pow(-1, float64(i)) / weight(x(t))
+			system[i][degree+2] = f64(x(t))
+		}
+		solution, err := solve(system)
+		if err != nil {
+			break
+		}
+		coeffs := solution[:degree+1]
+		levelled := math.Abs(solution[degree+1])
+
+		e, worst := errors(coeffs)
+		if worst < bestErr {
+			best, bestErr = coeffs, worst
+		}
+		if worst-levelled <= 1e-4*worst {
+			break
+		}
+
+		// New reference: the largest error on each run of the same sign,
+		// trimmed at the ends down to degree+2 points.
+		var extrema []int
+		for j := range e {
+			switch {
+			case len(extrema) == 0 || math.Signbit(e[j]) != math.Signbit(e[extrema[len(extrema)-1]]):
+				extrema = append(extrema, j)
+			case math.Abs(e[j]) > math.Abs(e[extrema[len(extrema)-1]]):
+				extrema[len(extrema)-1] = j
+			}
+		}
+		if len(extrema) < degree+2 {
+			break
+		}
+		for len(extrema) > degree+2 {
+			if math.Abs(e[extrema[0]]) < math.Abs(e[extrema[len(extrema)-1]]) {
+				extrema = extrema[1:]
+			} else {
+				extrema = extrema[:len(extrema)-1]
+			}
+		}
+		for i, j := range extrema {
+			reference[i] = grid[j]
+		}
+	}
+
+	return bignum.NewPolynomial(bignum.Chebyshev, best, [2]float64{K_left, K_right})
+}
+
+// RelativeWeight returns the weight 1/|f| on [a, b], which makes the minimax
+// approximation bound the relative error. It is floored at a thousandth of
+// the largest |f| on the interval, so that it stays finite around the zeros
+// of f.
+func RelativeWeight(a, b float64, f func(float64) float64) func(float64) float64 {
+	largest := 0.0
+	for j := 0; j <= 1024; j++ {
+		largest = math.Max(largest, math.Abs(f(a+float64(j)*(b-a)/1024)))
+	}
+	return func(x float64) float64 {
+		return 1 / math.Max(math.Abs(f(x)), 1e-3*largest)
+	}
+}
+
+// chebyshevBasis sets basis[k] to T_k(t).
+func chebyshevBasis(t float64, basis []float64) {
+	for k := range basis {
+		switch k {
+		case 0:
+			basis[k] = 1
+		case 1:
+			basis[k] = t
+		default:
+			basis[k] = 2*t*basis[k-1] - basis[k-2]
+		}
+	}
+}
+
+var errSingular = errors.New("singular system")
+
+// solve solves the linear system given as rows of an augmented matrix, by
+// Gaussian elimination with partial pivoting.
+func solve(system [][]float64) ([]float64, error) {
+	n := len(system)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(system[row][col]) > math.Abs(system[pivot][col]) {
+				pivot = row
+			}
+		}
+		if system[pivot][col] == 0 {
+			return nil, errSingular
+		}
+		system[col], system[pivot] = system[pivot], system[col]
+		for row := col + 1; row < n; row++ {
+			factor := system[row][col] / system[col][col]
+			for k := col; k <= n; k++ {
+				system[row][k] -= factor * system[col][k]
+			}
+		}
+	}
+	solution := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		solution[row] = system[row][n]
+		for k := row + 1; k < n; k++ {
+			solution[row] -= system[row][k] * solution[k]
+		}
+		solution[row] /= system[row][row]
+	}
+	return solution, nil
+}
@@ -0,0 +1,56 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/utils/bignum"
+)
+
+// weightedError returns max |weight(x) (f(x) - p(x))| on a fine grid of
+// [lo, hi], the interval of p.
+func weightedError(p bignum.Polynomial, lo, hi float64, f, weight func(float64) float64) (e float64) {
+	coeffs := make([]float64, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		coeffs[i], _ = c.Real().Float64()
+	}
+	for _, x := range grid(lo, hi, 20000) {
+		w := 1.0
+		if weight != nil {
+			w = weight(x)
+		}
+		e = math.Max(e, math.Abs(w*(f(x)-chebyshev(coeffs, (2*x-lo-hi)/(hi-lo)))))
+	}
+	return e
+}
+
+func TestGetMinimaxPoly(t *testing.T) {
+
+	for _, tc := range []struct {
+		activation string
+		lo, hi     float64
+		degree     int
+		relative   bool
+	}{
+		{"tanh", -8, 8, 15, false},
+		{"tanh", -8, 8, 31, false},
+		{"sin", -16, 16, 31, false},
+		{"exp", -2, 2, 7, false},
+		{"abs", -1, 1, 15, false},
+		{"log", 0.1, 6, 31, false},
+		{"log", 0.1, 6, 31, true},
+		{"sqrt", 0.1, 8, 15, true},
+	} {
+		f := Activations[tc.activation]
+		var weight func(float64) float64
+		if tc.relative {
+			weight = RelativeWeight(tc.lo, tc.hi, f)
+		}
+		minimax := weightedError(GetMinimaxPoly(tc.lo, tc.hi, tc.degree, f, weight), tc.lo, tc.hi, f, weight)
+		chebyshev := weightedError(GetChebyshevPoly(tc.lo, tc.hi, tc.degree, f), tc.lo, tc.hi, f, weight)
+		t.Logf("%s on [%g, %g] at degree %d, relative %v: minimax %.3e, interpolant %.3e", tc.activation, tc.lo, tc.hi, tc.degree, tc.relative, minimax, chebyshev)
+		if !(minimax <= chebyshev*(1+1e-9)) {
+			t.Errorf("%s on [%g, %g] at degree %d: minimax error %g above %g of the interpolant", tc.activation, tc.lo, tc.hi, tc.degree, minimax, chebyshev)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/utils/bignum"
+)
+
+// Activations maps the activation names used in model files to functions.
+var Activations = map[string]func(float64) float64{
+	"identity": func(x float64) float64 { return x },
+	"sin":      math.Sin,
+	"tan":      math.Tan,
+	"tanh":     math.Tanh,
+	"abs":      math.Abs,
+	"exp":      math.Exp,
+	"log":      math.Log,
+	"sqrt":     math.Sqrt,
+	"invsqrt":  func(x float64) float64 { return 1 / math.Sqrt(x) },
+	"inverse":  func(x float64) float64 { return 1 / x },
+	"pow2":     func(x float64) float64 { return x * x },
+	"pow3":     func(x float64) float64 { return x * x * x },
+}
+
+// NodeSpec describes a Node without its ciphertexts: Input indexes the outputs
+// of the previous layer, or the model features for the first layer.
+type NodeSpec struct {
+	Input             []int
+	Coefficients_mult []float64
+	Coefficient_add   float64
+	Activation        string
+	Activation_in     []float64 `json:",omitempty"` // {a, b}: the activation reads a*x + b, if set
+	Activation_out    []float64 `json:",omitempty"` // {c, d}: the node outputs c*y + d, if set
+	Interval          []float64
+	Degree            int
+	Approximation     string    `json:",omitempty"` // a key of Approximations or "leastsquares", Chebyshev interpolation if empty
+	Samples           []float64 `json:",omitempty"` // distribution of the input of the activation, for "leastsquares"
+	Strategy          string    `json:",omitempty"` // a key of Strategies, a single polynomial if empty
+	Breakpoints       []float64 `json:",omitempty"` // inputs where the activation is not smooth, for "piecewise"
+	Clamp             bool      `json:",omitempty"` // clip the affine input into Interval first, see ClampRange
+}
+
+// Function returns the activation of the node, composed with its input and
+// output maps.
+func (ns NodeSpec) Function() func(float64) float64 {
+	f, ok := Activations[ns.Activation]
+	if !ok {
+		panic(fmt.Errorf("unknown activation %q", ns.Activation))
+	}
+	if ns.Activation_in == nil && ns.Activation_out == nil {
+		return f
+	}
+	a, b, c, d := ns.Maps()
+	return func(x float64) float64 { return c*f(a*x+b) + d }
+}
+
+// Exact reports whether the activation of the node is a polynomial of degree
+// at most that of the node, evaluated by a single polynomial: identity, pow2
+// and pow3. Its approximation is then exact beyond its interval as well.
+func (ns NodeSpec) Exact() bool {
+	degree := map[string]int{"identity": 1, "pow2": 2, "pow3": 3}[ns.Activation]
+	return degree > 0 && degree <= ns.Degree && (ns.Strategy == "" || ns.Strategy == "polynomial")
+}
+
+// Approximator returns the approximation method of the node: one of
+// Approximations, or least squares on the samples of the node.
+func (ns NodeSpec) Approximator() Approximation {
+	if ns.Approximation == "leastsquares" {
+		samples := ns.Samples
+		return func(a, b float64, degree int, f func(float64) float64) bignum.Polynomial {
+			return GetLeastSquaresPoly(a, b, degree, f, samples)
+		}
+	}
+	approximation, ok := Approximations[ns.Approximation]
+	if !ok {
+		panic(fmt.Errorf("unknown approximation %q", ns.Approximation))
+	}
+	return approximation
+}
+
+// Maps returns the input map {a, b} and the output map {c, d} of the node,
+// the identity where unset.
+func (ns NodeSpec) Maps() (a, b, c, d float64) {
+	a, b, c, d = 1, 0, 1, 0
+	if ns.Activation_in != nil {
+		a, b = ns.Activation_in[0], ns.Activation_in[1]
+	}
+	if ns.Activation_out != nil {
+		c, d = ns.Activation_out[0], ns.Activation_out[1]
+	}
+	return a, b, c, d
+}
+
+// Layer is a Block whose outputs are bootstrapped before the next layer if
+// Bootstrap is set.
+type Layer struct {
+	Nodes     []NodeSpec
+	Bootstrap bool
+}
+
+// Tasks of a model: the outputs of a classifier are scores, compared to a
+// threshold or to each other for a label, while those of a regression model
+// are its predictions.
+const (
+	Classification = "classification"
+	Regression     = "regression"
+)
+
+// Model is a KAN as a sequence of layers, together with the names of its
+// inputs and the preprocessing they expect.
+type Model struct {
+	Name          string
+	Task          string `json:",omitempty"` // Classification if empty, or Regression
+	Features      []string
+	Ranges        [][]float64 // declared range of each feature after the preprocessing steps, if known
+	Preprocessing Pipeline
+	Layers        []Layer
+}
+
+// ReadModel reads a model file written by WriteModel.
+func ReadModel(filename string) (m Model, err error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return m, err
+	}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", filename, err)
+	}
+	return m, m.Check()
+}
+
+// WriteModel writes m as an indented JSON file.
+func WriteModel(filename string, m Model) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// LoadModel returns the built-in model of that name ("breast-cancer" or
+// "sepsis"), or else reads the model file.
+func LoadModel(name string) (Model, error) {
+	switch name {
+	case "breast-cancer":
+		return BreastCancerModel(), nil
+	case "sepsis":
+		return SepsisModel(), nil
+	}
+	return ReadModel(name)
+}
+
+// Check verifies that every input index, coefficient and activation of the
+// model is consistent.
+func (m Model) Check() error {
+	if m.Task != "" && m.Task != Classification && m.Task != Regression {
+		return fmt.Errorf("unknown task %q", m.Task)
+	}
+	if m.Ranges != nil && len(m.Ranges) != len(m.Features) {
+		return fmt.Errorf("%d ranges for %d features", len(m.Ranges), len(m.Features))
+	}
+	width := len(m.Features)
+	for l, layer := range m.Layers {
+		for i, ns := range layer.Nodes {
+			if len(ns.Input) != len(ns.Coefficients_mult) {
+				return fmt.Errorf("layer %d node %d: %d inputs but %d coefficients", l, i, len(ns.Input), len(ns.Coefficients_mult))
+			}
+			for _, k := range ns.Input {
+				if k < 0 || k >= width {
+					return fmt.Errorf("layer %d node %d: input %d out of range [0, %d)", l, i, k, width)
+				}
+			}
+			if _, ok := Activations[ns.Activation]; !ok {
+				return fmt.Errorf("layer %d node %d: unknown activation %q", l, i, ns.Activation)
+			}
+			if _, ok := Approximations[ns.Approximation]; !ok && ns.Approximation != "leastsquares" {
+				return fmt.Errorf("layer %d node %d: unknown approximation %q", l, i, ns.Approximation)
+			}
+			strategy, ok := Strategies[ns.Strategy]
+			if !ok {
+				return fmt.Errorf("layer %d node %d: unknown strategy %q", l, i, ns.Strategy)
+			}
+			if _, err := strategy(ns); err != nil {
+				return fmt.Errorf("layer %d node %d: %w", l, i, err)
+			}
+			if ns.Approximation == "leastsquares" && len(ns.Samples) == 0 {
+				return fmt.Errorf("layer %d node %d: least squares without samples", l, i)
+			}
+			if (ns.Activation_in != nil && len(ns.Activation_in) != 2) || (ns.Activation_out != nil && len(ns.Activation_out) != 2) {
+				return fmt.Errorf("layer %d node %d: activation maps must be pairs", l, i)
+			}
+			if len(ns.Interval) != 2 || ns.Interval[0] >= ns.Interval[1] {
+				return fmt.Errorf("layer %d node %d: invalid interval %v", l, i, ns.Interval)
+			}
+		}
+		width = len(layer.Nodes)
+	}
+	return nil
+}
+
+// IsRegression reports whether the outputs of the model are predictions
+// rather than class scores.
+func (m Model) IsRegression() bool {
+	return m.Task == Regression
+}
+
+// Evaluate computes the model on one plaintext sample given in the order of
+// m.Features, after preprocessing.
+func (m Model) Evaluate(x []float64) []float64 {
+	for _, layer := range m.Layers {
+		y := make([]float64, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			y[i] = ns.Function()(ns.Preactivation(x))
+		}
+		x = y
+	}
+	return x
+}
+
+// Preactivations returns the input of every activation of the model on one
+// sample, layer by layer.
+func (m Model) Preactivations(x []float64) (pre [][]float64) {
+	pre = make([][]float64, len(m.Layers))
+	for l, layer := range m.Layers {
+		pre[l] = make([]float64, len(layer.Nodes))
+		y := make([]float64, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			pre[l][i] = ns.Preactivation(x)
+			y[i] = ns.Function()(pre[l][i])
+		}
+		x = y
+	}
+	return pre
+}
+
+// Dead marks the nodes whose output reaches the model outputs through no
+// edge of nonzero weight.
+func (m Model) Dead() (dead [][]bool) {
+	dead = make([][]bool, len(m.Layers))
+	for l := len(m.Layers) - 1; l >= 0; l-- {
+		dead[l] = make([]bool, len(m.Layers[l].Nodes))
+		if l == len(m.Layers)-1 {
+			continue
+		}
+		for i := range dead[l] {
+			dead[l][i] = true
+		}
+		for n, ns := range m.Layers[l+1].Nodes {
+			for k, i := range ns.Input {
+				if !dead[l+1][n] && ns.Coefficients_mult[k] != 0 {
+					dead[l][i] = false
+				}
+			}
+		}
+	}
+	return dead
+}
+
+// FeatureIntervals returns, for each feature, the range of values for which
+// every live single-input node of the first layer reading it stays in its
+// interval. Features read by no such node get [-Inf, Inf].
+func (m Model) FeatureIntervals() (intervals [][]float64) {
+	intervals = make([][]float64, len(m.Features))
+	for j := range intervals {
+		intervals[j] = []float64{math.Inf(-1), math.Inf(1)}
+	}
+	if len(m.Layers) == 0 {
+		return intervals
+	}
+	dead := m.Dead()
+	for i, ns := range m.Layers[0].Nodes {
+		if dead[0][i] || len(ns.Input) != 1 || ns.Coefficients_mult[0] == 0 {
+			continue
+		}
+		w, j := ns.Coefficients_mult[0], ns.Input[0]
+		lo := (ns.Interval[0] - ns.Coefficient_add) / w
+		hi := (ns.Interval[1] - ns.Coefficient_add) / w
+		if w < 0 {
+			lo, hi = hi, lo
+		}
+		intervals[j][0] = math.Max(intervals[j][0], lo)
+		intervals[j][1] = math.Min(intervals[j][1], hi)
+	}
+	return intervals
+}
+
+// Preactivation returns the input of the activation of the node.
+func (ns NodeSpec) Preactivation(x []float64) (y float64) {
+	y = ns.Coefficient_add
+	for k, j := range ns.Input {
+		y += ns.Coefficients_mult[k] * x[j]
+	}
+	return y
+}
+
+// Forward evaluates the model on one ciphertext per feature, bootstrapping
+// with eval_boot after the layers that ask for it, and the clamps. It panics
+// before evaluating if the model does not Fit the levels of params.
+func (m Model) Forward(input []*rlwe.Ciphertext, eval *hefloat.Evaluator, eval_boot *bootstrapping.Evaluator, params hefloat.Parameters) (output []*rlwe.Ciphertext) {
+
+	if err := m.Fits(params.MaxLevel()); err != nil {
+		panic(err)
+	}
+	output = input
+	reserves, reach := m.Reserves(params.MaxLevel()), m.Reach()
+	for l, layer := range m.Layers {
+		bl, intervals, degrees := layer.Block(output)
+		bl.setup(reserves[l], reach[l], eval_boot)
+		output = bl.Forward(intervals, degrees, eval, params)
+		if layer.Bootstrap {
+			output = Bootstrap(eval_boot, output)
+		}
+	}
+	return output
+}
+
+// setup sets the reserve of the block, the reach of the clamps of its nodes
+// and their bootstrapping evaluator.
+func (bl *Block) setup(reserve int, reach []float64, eval_boot *bootstrapping.Evaluator) {
+	bl.Reserve = reserve
+	bl.Boot = eval_boot
+	for i := range bl.Nodes {
+		bl.Nodes[i].Reach = reach[i]
+	}
+}
+
+// Block instantiates the layer on the outputs of the previous layer. Clamped
+// nodes read their input rescaled to the interval [-1, 1].
+func (layer Layer) Block(previous []*rlwe.Ciphertext) (bl *Block, intervals [][]float64, degrees []int) {
+
+	num := len(layer.Nodes)
+	coefficients_mult := make([][]float64, num)
+	coefficient_add := make([]float64, num)
+	activation := make([]func(float64) float64, num)
+	input := make([][]*rlwe.Ciphertext, num)
+	intervals = make([][]float64, num)
+	degrees = make([]int, num)
+
+	for i, ns := range layer.Nodes {
+		ns = ns.clamped()
+		coefficients_mult[i] = ns.Coefficients_mult
+		coefficient_add[i] = ns.Coefficient_add
+		activation[i] = ns.Function()
+		input[i] = make([]*rlwe.Ciphertext, len(ns.Input))
+		for k, j := range ns.Input {
+			input[i][k] = previous[j]
+		}
+		intervals[i] = ns.Interval
+		degrees[i] = ns.Degree
+	}
+
+	bl = new(Block)
+	bl.Initialize(num, coefficients_mult, coefficient_add, activation, input)
+	for i, ns := range layer.Nodes {
+		ns = ns.clamped()
+		bl.Nodes[i].Approximation = ns.Approximator()
+		bl.Nodes[i].Strategy = ns.NewStrategy()
+		bl.Nodes[i].Clamp = ns.Clamp
+	}
+	return bl, intervals, degrees
+}
+
+// Bootstrap refreshes every ciphertext of input.
+func Bootstrap(eval_boot *bootstrapping.Evaluator, input []*rlwe.Ciphertext) (output []*rlwe.Ciphertext) {
+
+	var err error
+	output = make([]*rlwe.Ciphertext, len(input))
+	for i := range input {
+		if output[i], err = eval_boot.Bootstrap(input[i]); err != nil {
+			panic(err)
+		}
+	}
+	return output
+}
+
+// Schema returns the raw CSV columns the model reads before preprocessing.
+func (m Model) Schema() dataset.Schema {
+	return dataset.Schema{
+		Features: m.Preprocessing.Inputs(m.Features),
+		Label:    "label",
+	}
+}
+
+// Prepare applies the preprocessing to a dataset loaded with m.Schema() and
+// returns its rows in the order of m.Features.
+func (m Model) Prepare(d *dataset.Dataset) ([][]float64, error) {
+
+	columns, rows, err := m.Preprocessing.Apply(d.Schema.Features, d.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	position := make(map[string]int, len(columns))
+	for j, name := range columns {
+		position[name] = j
+	}
+	index := make([]int, len(m.Features))
+	for i, name := range m.Features {
+		j, ok := position[name]
+		if !ok {
+			return nil, fmt.Errorf("preprocessing does not produce feature %q", name)
+		}
+		index[i] = j
+	}
+
+	out := make([][]float64, len(rows))
+	for i := range rows {
+		out[i] = make([]float64, len(index))
+		for k, j := range index {
+			out[i][k] = rows[i][j]
+		}
+	}
+	return out, nil
+}
+
+// FoldPreprocessing moves the standardize and minmax steps into the first
+// layer's Coefficients_mult and Coefficient_add, so that the server applies
+// them for free inside Innerproduct. A step is folded only if no later step
+// touches its column. The client keeps the remaining steps, and the declared
+// range of a folded feature is mapped back to its values before the step.
+func (m Model) FoldPreprocessing() Model {
+
+	m = m.Clone()
+
+	var kept Pipeline
+	for s, st := range m.Preprocessing {
+		a, b, ok := st.Affine()
+		for _, later := range m.Preprocessing[s+1:] {
+			if later.Column == st.Column {
+				ok = false
+			}
+		}
+		feature := -1
+		for j, name := range m.Features {
+			if name == st.Column {
+				feature = j
+			}
+		}
+		if !ok || feature < 0 || len(m.Layers) == 0 {
+			kept = append(kept, st)
+			continue
+		}
+
+		nodes := m.Layers[0].Nodes
+		for i := range nodes {
+			for k, j := range nodes[i].Input {
+				if j == feature {
+					nodes[i].Coefficient_add += nodes[i].Coefficients_mult[k] * b
+					nodes[i].Coefficients_mult[k] *= a
+				}
+			}
+		}
+		if m.Ranges != nil && a != 0 {
+			lo, hi := (m.Ranges[feature][0]-b)/a, (m.Ranges[feature][1]-b)/a
+			m.Ranges[feature] = []float64{math.Min(lo, hi), math.Max(lo, hi)}
+		}
+	}
+	m.Preprocessing = kept
+
+	return m
+}
+
+// Clone returns a deep copy of m, so that transformations do not alias the
+// original slices.
+func (m Model) Clone() Model {
+
+	c := m
+	c.Features = append([]string(nil), m.Features...)
+	if m.Ranges != nil {
+		c.Ranges = make([][]float64, len(m.Ranges))
+		for j := range m.Ranges {
+			c.Ranges[j] = append([]float64(nil), m.Ranges[j]...)
+		}
+	}
+	c.Preprocessing = make(Pipeline, len(m.Preprocessing))
+	for s, st := range m.Preprocessing {
+		st.Categories = append([]float64(nil), st.Categories...)
+		c.Preprocessing[s] = st
+	}
+	c.Layers = make([]Layer, len(m.Layers))
+	for l, layer := range m.Layers {
+		c.Layers[l] = Layer{Nodes: make([]NodeSpec, len(layer.Nodes)), Bootstrap: layer.Bootstrap}
+		for i, ns := range layer.Nodes {
+			c.Layers[l].Nodes[i] = ns.clone()
+		}
+	}
+	return c
+}
+
+// clamped returns the node as evaluated: if it is clamped, a copy whose
+// affine input is rescaled to the interval [-1, 1], so that its strategy reads
+// the output of the clamp at no level. Other nodes are returned unchanged.
+func (ns NodeSpec) clamped() NodeSpec {
+	if !ns.Clamp {
+		return ns
+	}
+	ns = ns.clone()
+	lo, hi := ns.Interval[0], ns.Interval[1]
+	ns.mapInput(2/(hi-lo), -(hi+lo)/(hi-lo))
+	return ns
+}
+
+// clone returns a deep copy of the node.
+func (ns NodeSpec) clone() NodeSpec {
+	ns.Input = append([]int(nil), ns.Input...)
+	ns.Coefficients_mult = append([]float64(nil), ns.Coefficients_mult...)
+	ns.Interval = append([]float64(nil), ns.Interval...)
+	ns.Samples = append([]float64(nil), ns.Samples...)
+	ns.Breakpoints = append([]float64(nil), ns.Breakpoints...)
+	if ns.Activation_in != nil {
+		ns.Activation_in = append([]float64(nil), ns.Activation_in...)
+	}
+	if ns.Activation_out != nil {
+		ns.Activation_out = append([]float64(nil), ns.Activation_out...)
+	}
+	return ns
+}
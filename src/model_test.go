@@ -0,0 +1,147 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+)
+
+// TestForwardStrategies runs Model.Forward on a layer whose nodes name each a
+// strategy, on points of the declared feature ranges, ends included. Every
+// node must consume the levels it plans, the model the depth its Cost
+// reports, and the outputs match the plaintext model within the error of the
+// strategy: the piecewise abs, of degree 15, blends its pieces over a wide
+// part of the interval at that depth.
+func TestForwardStrategies(t *testing.T) {
+
+	k := newTestKeys(t)
+	node := func(input int, w, b float64, activation string, interval []float64, degree int, strategy string) NodeSpec {
+		return NodeSpec{
+			Input:             []int{input},
+			Coefficients_mult: []float64{w},
+			Coefficient_add:   b,
+			Activation:        activation,
+			Interval:          interval,
+			Degree:            degree,
+			Strategy:          strategy,
+		}
+	}
+	nodes := []struct {
+		ns        NodeSpec
+		tolerance float64
+	}{
+		{node(0, 1, 0.37, "sqrt", []float64{0.1, 8}, 31, "sqrt"), 1e-6},
+		{node(1, -1.38, 4.25, "log", []float64{0.1, 6}, 31, "log"), 1e-5},
+		{node(2, 2.93, 0, "exp", []float64{-8, 8}, 31, "exp"), 1e-4},
+		{node(3, 0.5, 1, "inverse", []float64{0.5, 4}, 31, "inverse"), 1e-6},
+		{node(4, 1, 0, "abs", []float64{-2, 2}, 15, "piecewise"), 0.15},
+		{node(5, 0.58, -0.48, "tanh", []float64{-4, 4}, 31, "polynomial"), 1e-4},
+	}
+	m := Model{Name: "strategies", Layers: []Layer{{}}}
+	for j, n := range nodes {
+		m.Features = append(m.Features, string(rune('a'+j)))
+		m.Layers[0].Nodes = append(m.Layers[0].Nodes, n.ns)
+	}
+	m.Ranges = m.FeatureIntervals()
+	if err := m.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Points of the feature ranges, ends included.
+	num := 16
+	features := make([][]float64, len(m.Features))
+	for j, r := range m.Ranges {
+		features[j] = grid(r[0], r[1], num)
+	}
+	output := m.Forward(k.encrypt(t, features), k.eval, k.eval_boot, k.params)
+	depth := 0
+	for i, n := range nodes {
+		levels := k.params.MaxLevel() - output[i].Level()
+		if plan := n.ns.Levels(k.params.MaxLevel()); levels != plan {
+			t.Errorf("%s: %d levels, planned %d", n.ns.Strategy, levels, plan)
+		}
+		depth = max(depth, levels)
+		got := k.decrypt(t, output[i], num)
+		for s := range got {
+			x := make([]float64, len(features))
+			for j := range x {
+				x[j] = features[j][s]
+			}
+			if want := m.Evaluate(x)[i]; !(math.Abs(got[s]-want) < n.tolerance) {
+				t.Errorf("%s: %s(%g) = %g, want %g", n.ns.Strategy, n.ns.Activation, n.ns.Preactivation(x), got[s], want)
+			}
+		}
+	}
+	if cost := m.Cost(k.params.MaxLevel()); depth != cost.Levels {
+		t.Errorf("depth %d, cost of %d levels", depth, cost.Levels)
+	}
+}
+
+// TestFoldPreprocessing checks that the breast model, and variants with a
+// standardize step, an imputation before it and a step that cannot be folded,
+// give the same outputs on raw rows once their affine steps are folded into
+// the first layer: m on Prepare(raw) against FoldPreprocessing(m) on the
+// preparation of the steps it keeps.
+func TestFoldPreprocessing(t *testing.T) {
+
+	breast := BreastCancerModel()
+	data, err := dataset.Load("../data/test_data_breast-cancer.csv", dataset.Schema{Features: breast.Features})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test set holds the scores after the minmax steps.
+	raw := make([][]float64, len(data.Rows))
+	for i, row := range data.Rows {
+		raw[i] = make([]float64, len(row))
+		for j, x := range row {
+			raw[i][j] = math.Round(1 + 9*x)
+		}
+	}
+	missing := append([][]float64{append([]float64(nil), raw[0]...)}, raw[1:]...)
+	missing[0][5] = math.NaN()
+
+	standardized := breast.Clone()
+	standardized.Preprocessing[5] = Step{Kind: "standardize", Column: breast.Features[5], Mean: 3.5, Std: 3.6}
+	standardized.Ranges[5] = []float64{-0.7, 1.8}
+	imputed := standardized.Clone()
+	imputed.Preprocessing = append(Pipeline{{Kind: "impute", Column: breast.Features[5], Value: 1}}, imputed.Preprocessing...)
+	unfoldable := imputed.Clone()
+	unfoldable.Preprocessing = append(unfoldable.Preprocessing, Step{Kind: "impute", Column: breast.Features[0], Value: 0})
+
+	for _, tc := range []struct {
+		name string
+		m    Model
+		rows [][]float64
+		kept int // steps left to the client
+	}{
+		{"minmax", breast, raw, 0},
+		{"standardize", standardized, raw, 0},
+		{"impute then standardize", imputed, missing, 1},
+		{"step after minmax", unfoldable, missing, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &dataset.Dataset{Schema: tc.m.Schema(), Rows: tc.rows}
+			folded := tc.m.FoldPreprocessing()
+			if len(folded.Preprocessing) != tc.kept {
+				t.Errorf("%d steps kept, want %d", len(folded.Preprocessing), tc.kept)
+			}
+			prepared, err := tc.m.Prepare(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			foldedPrepared, err := folded.Prepare(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tc.rows {
+				want, got := tc.m.Evaluate(prepared[i]), folded.Evaluate(foldedPrepared[i])
+				for j := range want {
+					if !(math.Abs(got[j]-want[j]) <= 1e-9*math.Max(1, math.Abs(want[j]))) {
+						t.Fatalf("row %d output %d: %g, want %g", i, j, got[j], want[j])
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package src
 
 import (
+	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
@@ -9,64 +11,137 @@ import (
 )
 
 type Node struct {
-	Coefficients_mult []float64  // must not be integer
+	Coefficients_mult []float64
 	Coefficient_add float64
 	Activation func (float64) (float64)	
 	Input []*rlwe.Ciphertext
+	Approximation Approximation // GetChebyshevPoly if nil
+	Strategy Strategy // PolynomialStrategy if nil
+	Clamp bool // clip the inner product into the interval before the activation
+	Reach float64 // half-width of the range of the clamp, in half-widths of the interval, see Model.Reach
 }
 
+// Forward evaluates the node with its strategy, which may spend every level
+// left on the inputs.
 func (n Node) Forward(interval []float64, degree int, eval *hefloat.Evaluator, params hefloat.Parameters) (output *rlwe.Ciphertext) {
-	
-	var err error
-	output = Innerproduct(n.Coefficients_mult, n.Coefficient_add, n.Input, eval)
-	
-	poly := hefloat.NewPolynomial(GetChebyshevPoly(interval[0], interval[1], degree, n.Activation))
-	polyEval := hefloat.NewPolynomialEvaluator(params, eval)
 
-	scalar, constant := poly.ChangeOfBasis()
+	output, _ = n.evaluate(interval, degree, n.Input[0].Level(), NewEvaluation(eval, params))
+	return output
+}
 
-	if err := eval.Mul(output, scalar, output); err != nil {
-		panic(err)
+// evaluate runs the strategy of the node within budget levels, on the clamped
+// inner product if n.Clamp. The output of the clamp, in [-1, 1], is then
+// bootstrapped with ev.Boot, and the strategy reads it as a single input of
+// weight 1, whose change of basis is free on [-1, 1], leaving on the refreshed
+// levels the reserve it would have left on the inputs. The depth of a clamped
+// node is that of its clamp, the levels it consumes on its inputs.
+func (n Node) evaluate(interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	if !n.Clamp {
+		return n.strategy().Evaluate(n, interval, degree, budget, ev)
 	}
-	if err := eval.Add(output, constant, output); err != nil {
-		panic(err)
+	if ev.Boot == nil {
+		panic(fmt.Errorf("clamp without a bootstrapping evaluator"))
 	}
-	if err := eval.Rescale(output, output); err != nil {
-		panic(err)
+	reserve := n.Input[0].Level() - budget
+	clamped, depth := n.clamp(interval, budget, ev)
+	clamped = Bootstrap(ev.Boot, []*rlwe.Ciphertext{clamped})[0]
+	inner := n
+	inner.Coefficients_mult = []float64{1}
+	inner.Coefficient_add = 0
+	inner.Input = []*rlwe.Ciphertext{clamped}
+	output, _ = n.strategy().Evaluate(inner, interval, degree, clamped.Level()-reserve, ev)
+	return output, depth
+}
+
+func (n Node) strategy() Strategy {
+	if n.Strategy == nil {
+		return PolynomialStrategy{}
 	}
-	if output, err = polyEval.Evaluate(output, poly, params.DefaultScale()); err != nil {
-		panic(err)
+	return n.Strategy
+}
+
+// Polynomial returns the approximation of the activation on interval,
+// and the weights and constant of the inner product fused with its change of
+// basis x -> scalar*x + constant. The inner product then consumes a single
+// level, or none if the fused weights are integers.
+func (n Node) Polynomial(interval []float64, degree int) (poly hefloat.Polynomial, coefficients_mult []float64, coefficient_add float64) {
+
+	approximation := n.Approximation
+	if approximation == nil {
+		approximation = GetChebyshevPoly
 	}
-	return output
+	poly = hefloat.NewPolynomial(approximation(interval[0], interval[1], degree, n.Activation))
+
+	scalar, constant := poly.ChangeOfBasis()
+	s, _ := scalar.Float64()
+	c, _ := constant.Float64()
+	coefficients_mult = make([]float64, len(n.Coefficients_mult))
+	for i := range coefficients_mult {
+		coefficients_mult[i] = s * n.Coefficients_mult[i]
+	}
+	return poly, coefficients_mult, s*n.Coefficient_add + c
 }
 
+// Innerproduct computes sum coefficients_mult[i]*input[i] + coefficient_add.
+// Zero weights are skipped. Integer weights leave the scale unchanged, so the
+// result is rescaled only if some weight is fractional, in which case the
+// integer terms are scaled up to match the fractional ones. The inputs must
+// share their level and scale.
 func Innerproduct(coefficients_mult []float64, coefficient_add float64, input []*rlwe.Ciphertext, eval *hefloat.Evaluator) (output *rlwe.Ciphertext) {
 
 	var err error
-	num := len(input)
-	tmp := make([]*rlwe.Ciphertext, len(input))
-	for i:=0;i<num;i++ {
-		if tmp[i], err = eval.MulNew(input[i], coefficients_mult[i]); err != nil {
+	if len(input) == 0 {
+		panic("Innerproduct needs at least one input")
+	}
+
+	fractional := false
+	for i:=0;i<len(input);i++ {
+		if coefficients_mult[i] != math.Trunc(coefficients_mult[i]) {
+			fractional = true
+		}
+	}
+	// Multiplying by a fractional constant scales the result by the current
+	// modulus, which the final rescale divides back.
+	level := input[0].Level()
+	scale := rlwe.NewScale(eval.GetParameters().RingQ().SubRings[level].Modulus)
+
+	for i:=0;i<len(input);i++ {
+		w := coefficients_mult[i]
+		if w == 0 {
+			continue
+		}
+		tmp, err := eval.MulNew(input[i], w)
+		if err != nil {
+			panic(err)
+		}
+		if fractional && w == math.Trunc(w) {
+			if err = eval.ScaleUp(tmp, scale, tmp); err != nil {
+				panic(err)
+			}
+		}
+		if output == nil {
+			output = tmp
+		} else if err = eval.Add(output, tmp, output); err != nil {
 			panic(err)
 		}
-		// if err := eval.Rescale(tmp[i], tmp[i]); err != nil {
-		// 	panic(err)
-		// }
 	}
 
-	output = tmp[0]
-	for i:=1;i<num;i++ {
-		if err := eval.Add(output, tmp[i], output); err != nil {
+	// Every weight is 0: the result is the constant, on a zeroed input.
+	if output == nil {
+		if output, err = eval.MulNew(input[0], 0); err != nil {
 			panic(err)
 		}
 	}
 
-	if err := eval.Add(output, coefficient_add, output); err != nil {
+	if err = eval.Add(output, coefficient_add, output); err != nil {
 		panic(err)
 	}
 
-	if err := eval.Rescale(output, output); err != nil {
-		panic(err)
+	if fractional {
+		if err = eval.Rescale(output, output); err != nil {
+			panic(err)
+		}
 	}
 
 	return output
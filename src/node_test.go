@@ -0,0 +1,48 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInnerproduct(t *testing.T) {
+
+	k := newTestKeys(t)
+	inputs := [][]float64{
+		{0.5, -0.25, 1, 0, -1, 0.75},
+		{-0.5, 0.125, 0.3, 1, -0.7, 0.2},
+		{1, 0.9, -0.6, 0.4, 0, -0.35},
+	}
+	for _, tc := range []struct {
+		name    string
+		weights []float64
+		add     float64
+		levels  int // levels consumed
+	}{
+		{"integer", []float64{2, -1, 3}, 0.5, 0},
+		{"zero", []float64{0, 0, 0}, -0.25, 0},
+		{"mixed", []float64{2, 0.37, -1.5}, 0.1, 1},
+		{"negative integer", []float64{-3, -1, -2}, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			input := k.encrypt(t, inputs)
+			output := Innerproduct(tc.weights, tc.add, input, k.eval)
+			if got, want := output.Level(), input[0].Level()-tc.levels; got != want {
+				t.Errorf("level %d, want %d", got, want)
+			}
+			if got, want := output.Scale.Float64(), input[0].Scale.Float64(); math.Abs(got/want-1) > 1e-9 {
+				t.Errorf("scale 2^%.6f, want 2^%.6f", math.Log2(got), math.Log2(want))
+			}
+			got := k.decrypt(t, output, len(inputs[0]))
+			for i := range got {
+				want := tc.add
+				for j, w := range tc.weights {
+					want += w * inputs[j][i]
+				}
+				if math.Abs(got[i]-want) > 1e-6 {
+					t.Errorf("slot %d: %g, want %g", i, got[i], want)
+				}
+			}
+		})
+	}
+}
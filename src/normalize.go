@@ -0,0 +1,90 @@
+package src
+
+import "math"
+
+// Normalize returns a copy of m in which every live node reads and, except in
+// the last layer, outputs values in [-1, 1] over the calibrated bounds b, for
+// instance the EmpiricalBounds of a calibration set, widened by margin times
+// their width on each side. The affine input of a node is rescaled so that
+// its bound maps to its new interval [-1, 1], the input map of the activation
+// undoes it; the output map rescales the bound of the output to [-1, 1], and
+// the weights and constants of the consumers undo it. The model computes the
+// same function, up to rounding; only the intervals change.
+func (m Model) Normalize(b Bounds, margin float64) Model {
+
+	m = m.Clone()
+	dead := m.Dead()
+	last := len(m.Layers) - 1
+
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			ns := &m.Layers[l].Nodes[i]
+			if dead[l][i] {
+				continue
+			}
+
+			// Input: x -> s*x + t maps [lo, hi] to [-1, 1].
+			if s, t, ok := normalization(b.Pre[l][i], margin, ns.Function()); ok {
+				ns.mapInput(s, t)
+			}
+
+			// Output: y -> s*y + t, undone in the next layer.
+			if l == last {
+				continue
+			}
+			s, t, ok := normalization(b.Post[l][i], margin, nil)
+			if !ok {
+				continue
+			}
+			_, _, c, d := ns.Maps()
+			ns.Activation_out = []float64{s * c, s*d + t}
+			for n := range m.Layers[l+1].Nodes {
+				consumer := &m.Layers[l+1].Nodes[n]
+				for k, j := range consumer.Input {
+					if j == i {
+						consumer.Coefficient_add -= consumer.Coefficients_mult[k] * t / s
+						consumer.Coefficients_mult[k] /= s
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// mapInput rescales the affine input x of the node, its samples and
+// breakpoints, to s*x + t, which the input map of the activation undoes, and
+// sets its interval to [-1, 1].
+func (ns *NodeSpec) mapInput(s, t float64) {
+	for k := range ns.Coefficients_mult {
+		ns.Coefficients_mult[k] *= s
+	}
+	ns.Coefficient_add = s*ns.Coefficient_add + t
+	a, b, _, _ := ns.Maps()
+	ns.Activation_in = []float64{a / s, b - a*t/s}
+	ns.Interval = []float64{-1, 1}
+	for k := range ns.Samples {
+		ns.Samples[k] = s*ns.Samples[k] + t
+	}
+	for k := range ns.Breakpoints {
+		ns.Breakpoints[k] = s*ns.Breakpoints[k] + t
+	}
+}
+
+// normalization returns the affine map x -> s*x + t sending bound, widened by
+// margin, to [-1, 1]. A side is not widened where f, if given, is undefined,
+// as log or sqrt below 0. It fails on empty, unbounded or single-point bounds.
+func normalization(bound []float64, margin float64, f func(float64) float64) (s, t float64, ok bool) {
+	lo, hi := bound[0], bound[1]
+	if math.IsInf(lo, 0) || math.IsInf(hi, 0) || math.IsNaN(lo) || math.IsNaN(hi) || lo >= hi {
+		return 0, 0, false
+	}
+	pad := margin * (hi - lo)
+	if f == nil || !math.IsNaN(f(lo-pad)) {
+		lo -= pad
+	}
+	if f == nil || !math.IsNaN(f(hi+pad)) {
+		hi += pad
+	}
+	return 2 / (hi - lo), -(hi + lo) / (hi - lo), true
+}
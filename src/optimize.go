@@ -0,0 +1,332 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Cost counts what the encrypted evaluation of a model spends.
+type Cost struct {
+	Layers     int
+	Inputs     int // ciphertexts encrypted by the client
+	Nodes      int // polynomial evaluations
+	Bases      int // Chebyshev power bases, shared by the nodes with the same fused inner product
+	Edges      int // scalar multiplications of the inner products, fused with the change of basis
+	Levels     int // levels consumed, bootstrapping aside
+	Bootstraps int // ciphertexts bootstrapped
+}
+
+// Cost returns the cost of evaluating m with Model.Forward on parameters of
+// maxLevel levels, see NodeSpec.Levels; math.MaxInt leaves the strategies
+// unlimited.
+func (m Model) Cost(maxLevel int) (c Cost) {
+	c.Layers = len(m.Layers)
+	c.Inputs = len(m.Features)
+	for _, layer := range m.Layers {
+		levels := 0
+		for n, ns := range layer.Nodes {
+			c.Nodes++
+			if !slices.ContainsFunc(layer.Nodes[:n], ns.sharesBasis) {
+				c.Bases++
+			}
+			scalar := 2 / (ns.Interval[1] - ns.Interval[0])
+			for _, w := range ns.Coefficients_mult {
+				if scalar*w != 1 {
+					c.Edges++
+				}
+			}
+			levels = max(levels, ns.Levels(maxLevel))
+			if ns.Clamp {
+				c.Bootstraps++
+			}
+		}
+		c.Levels += levels
+		if layer.Bootstrap {
+			c.Bootstraps += len(layer.Nodes)
+		}
+	}
+	return c
+}
+
+// sharesBasis reports whether Block.Forward computes one power basis for ns
+// and o: whether they have the same inputs, weights, constant and interval.
+func (ns NodeSpec) sharesBasis(o NodeSpec) bool {
+	return ns.Coefficient_add == o.Coefficient_add && slices.Equal(ns.Input, o.Input) &&
+		slices.Equal(ns.Coefficients_mult, o.Coefficients_mult) && slices.Equal(ns.Interval, o.Interval)
+}
+
+// Reserves returns, for each layer, the levels the layers after it consume
+// before the next bootstrapping, which its strategies must leave, on
+// parameters of maxLevel levels.
+func (m Model) Reserves(maxLevel int) []int {
+	reserves := make([]int, len(m.Layers))
+	for l := len(m.Layers) - 2; l >= 0; l-- {
+		if m.Layers[l].Bootstrap {
+			continue
+		}
+		levels := 0
+		for _, ns := range m.Layers[l+1].Nodes {
+			levels = max(levels, ns.Levels(maxLevel))
+		}
+		reserves[l] = reserves[l+1] + levels
+	}
+	return reserves
+}
+
+// Levels returns the levels the node consumes on its inputs with its strategy,
+// given a budget of maxLevel, the most a node can have. A strategy that
+// chooses its plan by depth, as PiecewiseStrategy, plans on a shallower
+// chain than with an unlimited budget. Once the model Fits, every node of
+// Forward has at least these levels beyond its reserve, and its strategy
+// runs the same plan. A clamped node consumes those of its clamp on its
+// inputs, and its strategy those of the bootstrapped output of the clamp, see
+// Fits.
+func (ns NodeSpec) Levels(maxLevel int) int {
+	if ns.Clamp {
+		return ClampDepth()
+	}
+	return ns.NewStrategy().Depth(ns.Coefficients_mult, ns.Interval, ns.Degree, maxLevel)
+}
+
+// activationLevels returns the levels the strategy of a clamped node consumes
+// on the output of its clamp, given a budget of maxLevel.
+func (ns NodeSpec) activationLevels(maxLevel int) int {
+	ns = ns.clamped()
+	return ns.NewStrategy().Depth([]float64{1}, ns.Interval, ns.Degree, maxLevel)
+}
+
+// Fits verifies, before an evaluation, that every node of m fits in maxLevel,
+// the level of fresh and bootstrapped ciphertexts: each layer finds the levels
+// of its nodes and their reserves left since the last bootstrapping, and the
+// strategy of a clamped node its levels and reserve on the bootstrapped
+// output of its clamp, whose Reach must be bounded.
+func (m Model) Fits(maxLevel int) error {
+	reserves := m.Reserves(maxLevel)
+	reach := m.Reach()
+	level := maxLevel
+	for l, layer := range m.Layers {
+		levels := 0
+		for i, ns := range layer.Nodes {
+			if need := ns.Levels(maxLevel) + reserves[l]; need > level {
+				return fmt.Errorf("layer %d node %d: %d levels needed, %d left", l, i, need, level)
+			}
+			if !ns.Clamp {
+				levels = max(levels, ns.Levels(maxLevel))
+				continue
+			}
+			if need := ns.activationLevels(maxLevel) + reserves[l]; need > maxLevel {
+				return fmt.Errorf("layer %d node %d: %d levels needed after the clamp, %d left", l, i, need, maxLevel)
+			}
+			if math.IsInf(reach[l][i], 0) || math.IsNaN(reach[l][i]) {
+				return fmt.Errorf("layer %d node %d: clamp of an unbounded inner product", l, i)
+			}
+			levels = max(levels, ns.Levels(maxLevel))
+		}
+		level -= levels
+		if layer.Bootstrap {
+			level = maxLevel
+		}
+	}
+	return nil
+}
+
+// Optimize returns a copy of m computing the same function at a lower cost:
+// edges of weight 0 are removed, nodes left without inputs are folded as
+// constants into their consumers, nodes whose outputs are not used anymore are
+// removed, and so are the features no node reads. A layer made only of
+// single-input identity nodes, each reading its own node of the previous
+// layer, applies an affine map per node: it is folded into the output maps of
+// the previous layer and removed.
+func (m Model) Optimize() Model {
+
+	m = m.Clone()
+	for {
+		before := m.Cost(math.MaxInt)
+		m = m.removeZeroEdges()
+		m = m.removeDeadNodes()
+		m = m.removeUnusedFeatures()
+		m = m.foldAffineLayers()
+		if m.Cost(math.MaxInt) == before {
+			return m
+		}
+	}
+}
+
+// removeZeroEdges removes the edges of weight 0, and folds the nodes left
+// without inputs into the constants of their consumers. Nodes of the last
+// layer keep at least one edge, as a Node needs an input ciphertext.
+func (m Model) removeZeroEdges() Model {
+
+	last := len(m.Layers) - 1
+	for l := range m.Layers {
+		for i := range m.Layers[l].Nodes {
+			ns := &m.Layers[l].Nodes[i]
+			var input []int
+			var weights []float64
+			for k, j := range ns.Input {
+				if ns.Coefficients_mult[k] != 0 {
+					input = append(input, j)
+					weights = append(weights, ns.Coefficients_mult[k])
+				}
+			}
+			if len(input) == 0 && l == last {
+				continue
+			}
+			ns.Input, ns.Coefficients_mult = input, weights
+
+			if len(input) != 0 || l == last {
+				continue
+			}
+			value := ns.Function()(ns.Coefficient_add)
+			for n := range m.Layers[l+1].Nodes {
+				consumer := &m.Layers[l+1].Nodes[n]
+				for k, j := range consumer.Input {
+					if j == i {
+						consumer.Coefficient_add += consumer.Coefficients_mult[k] * value
+						consumer.Coefficients_mult[k] = 0
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// removeDeadNodes removes the nodes that reach no output, and renumbers the
+// inputs of their consumers.
+func (m Model) removeDeadNodes() Model {
+
+	dead := m.Dead()
+	for l := range m.Layers {
+		index := make([]int, len(m.Layers[l].Nodes))
+		var nodes []NodeSpec
+		for i, ns := range m.Layers[l].Nodes {
+			index[i] = len(nodes)
+			if !dead[l][i] {
+				nodes = append(nodes, ns)
+			}
+		}
+		m.Layers[l].Nodes = nodes
+		if l+1 < len(m.Layers) {
+			m.Layers[l+1].renumber(index, dead[l])
+		}
+	}
+	return m
+}
+
+// removeUnusedFeatures removes the features no node of the first layer reads.
+func (m Model) removeUnusedFeatures() Model {
+
+	if len(m.Layers) == 0 {
+		return m
+	}
+	unused := make([]bool, len(m.Features))
+	for j := range unused {
+		unused[j] = true
+	}
+	for _, ns := range m.Layers[0].Nodes {
+		for _, j := range ns.Input {
+			unused[j] = false
+		}
+	}
+
+	index := make([]int, len(m.Features))
+	var features []string
+	var ranges [][]float64
+	for j, name := range m.Features {
+		index[j] = len(features)
+		if unused[j] {
+			continue
+		}
+		features = append(features, name)
+		if m.Ranges != nil {
+			ranges = append(ranges, m.Ranges[j])
+		}
+	}
+	m.Features, m.Ranges = features, ranges
+	m.Layers[0].renumber(index, unused)
+	return m
+}
+
+// foldAffineLayers removes the layers whose nodes are all single-input
+// identity nodes reading distinct nodes of the previous layer.
+func (m Model) foldAffineLayers() Model {
+
+	for l := 1; l < len(m.Layers); l++ {
+		layer, previous := m.Layers[l], m.Layers[l-1]
+		if len(layer.Nodes) != len(previous.Nodes) {
+			continue
+		}
+		read := make([]bool, len(previous.Nodes))
+		foldable := true
+		for _, ns := range layer.Nodes {
+			if ns.Activation != "identity" || len(ns.Input) != 1 || read[ns.Input[0]] {
+				foldable = false
+				break
+			}
+			read[ns.Input[0]] = true
+		}
+		if !foldable {
+			continue
+		}
+
+		// Node i of layer l computes alpha*(w*y + add) + beta of the output y
+		// of its producer, which takes its place.
+		nodes := make([]NodeSpec, len(layer.Nodes))
+		for i, ns := range layer.Nodes {
+			a, b, c, d := ns.Maps()
+			alpha, beta := c*a, c*b+d
+			w, add := ns.Coefficients_mult[0], ns.Coefficient_add
+
+			producer := previous.Nodes[ns.Input[0]]
+			_, _, pc, pd := producer.Maps()
+			scale := alpha * w
+			producer.Activation_out = []float64{scale * pc, scale*pd + alpha*add + beta}
+			nodes[i] = producer
+		}
+		m.Layers[l-1] = Layer{Nodes: nodes, Bootstrap: previous.Bootstrap || layer.Bootstrap}
+		m.Layers = append(m.Layers[:l], m.Layers[l+1:]...)
+		l--
+	}
+	return m
+}
+
+// renumber points the inputs of the layer to the new indices of the outputs of
+// the previous layer, dropping the edges from removed outputs.
+func (layer Layer) renumber(index []int, removed []bool) {
+	for i := range layer.Nodes {
+		ns := &layer.Nodes[i]
+		var input []int
+		var weights []float64
+		for k, j := range ns.Input {
+			if !removed[j] {
+				input = append(input, index[j])
+				weights = append(weights, ns.Coefficients_mult[k])
+			}
+		}
+		ns.Input, ns.Coefficients_mult = input, weights
+	}
+}
+
+// Equivalent reports the largest difference between the outputs of m and o on
+// the rows, relative to the magnitude of the outputs of m where it exceeds 1.
+// The rows are given in the order of m.Features; o may read fewer features.
+func (m Model) Equivalent(o Model, rows [][]float64) (worst float64) {
+
+	position := make(map[string]int, len(m.Features))
+	for j, name := range m.Features {
+		position[name] = j
+	}
+	x := make([]float64, len(o.Features))
+	for _, row := range rows {
+		for j, name := range o.Features {
+			x[j] = row[position[name]]
+		}
+		want, have := m.Evaluate(row), o.Evaluate(x)
+		for j := range want {
+			worst = math.Max(worst, math.Abs(want[j]-have[j])/math.Max(1, math.Abs(want[j])))
+		}
+	}
+	return worst
+}
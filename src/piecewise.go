@@ -0,0 +1,261 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// SignChains lists the composite approximations of the sign function that
+// PiecewiseStrategy may use, by increasing depth: {a, b} composes a times the
+// degree 7 polynomial g_3 of Cheon, Kim and Kim, whose slope at 0 moves inputs
+// quickly away from it, then b times f_3 = (35t - 35t^3 + 21t^5 - 5t^7)/16,
+// which flattens the output near -1 and 1. Each composition consumes three
+// levels; the last two reach 1e-4 of sign beyond 0.03 and 0.003.
+var SignChains = [][2]int{{0, 1}, {0, 2}, {1, 2}, {2, 2}, {3, 2}, {3, 3}}
+
+// PiecewiseStrategy evaluates an activation that is smooth only between
+// breakpoints b_1 < ... < b_m of the interval, as abs at 0 or a clipped range
+// at its ends. Each segment gets a polynomial g_j of low degree fitted on the
+// segment alone, and the pieces are blended as sum_j w_j g_j, where the
+// indicators w_0 = 1 - s_1, w_j = s_j - s_(j+1) and w_m = s_m of the steps
+// s_j = step(x - b_j) sum to 1 exactly. A step is one of SignChains on
+// (x - b_j)/r, r the largest distance from b_j to an end of the interval,
+// mapped to [0, 1]. The error concentrates around the breakpoints, where
+// neighbouring pieces nearly agree for a continuous activation. The degree of
+// the pieces and the chain are chosen on a plaintext simulation, with a single
+// polynomial of the node degree as one more candidate.
+type PiecewiseStrategy struct {
+	Breakpoints []float64
+	Activation  func(float64) float64 // activation of the node, through its maps
+}
+
+// NewPiecewiseStrategy returns the PiecewiseStrategy on the breakpoints of the
+// node, or at the kink of abs if it declares none.
+func NewPiecewiseStrategy(ns NodeSpec) (Strategy, error) {
+	breakpoints := append([]float64(nil), ns.Breakpoints...)
+	if len(breakpoints) == 0 && ns.Activation == "abs" {
+		if a, b, _, _ := ns.Maps(); a != 0 {
+			breakpoints = []float64{-b / a}
+		}
+	}
+	sort.Float64s(breakpoints)
+	s := PiecewiseStrategy{Breakpoints: breakpoints, Activation: ns.Function()}
+	if len(s.inside(ns.Interval)) == 0 {
+		return nil, fmt.Errorf("piecewise strategy without breakpoints inside %v", ns.Interval)
+	}
+	return s, nil
+}
+
+func (s PiecewiseStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	var err error
+	level := n.Input[0].Level()
+	degree, chain := s.plan(n.Coefficients_mult, interval, degree, budget)
+	if chain < 0 {
+		return PolynomialStrategy{}.Evaluate(n, interval, degree, budget, ev)
+	}
+	breakpoints := s.inside(interval)
+
+	steps := make([]*rlwe.Ciphertext, len(breakpoints))
+	for j := range breakpoints {
+		steps[j] = evaluateStep(n, breakpoints[j], interval, SignChains[chain], budget, ev)
+	}
+
+	for j, g := range s.pieces(interval, degree) {
+		var w *rlwe.Ciphertext
+		switch {
+		case j == 0:
+			if w, err = ev.Eval.MulNew(steps[0], -1); err != nil {
+				panic(err)
+			}
+			if err = ev.Eval.Add(w, 1, w); err != nil {
+				panic(err)
+			}
+		case j == len(steps):
+			w = steps[j-1]
+		default:
+			if w, err = ev.Eval.SubNew(steps[j-1], steps[j]); err != nil {
+				panic(err)
+			}
+		}
+
+		piece := n
+		piece.Activation = g
+		piece.Approximation = GetChebyshevPoly
+		y, _ := PolynomialStrategy{}.Evaluate(piece, interval, degree, budget, ev)
+
+		if y, err = ev.Eval.MulRelinNew(w, y); err != nil {
+			panic(err)
+		}
+		if err = ev.Eval.Rescale(y, y); err != nil {
+			panic(err)
+		}
+		if output == nil {
+			output = y
+		} else if err = ev.Eval.Add(output, y, output); err != nil {
+			panic(err)
+		}
+	}
+	return output, level - output.Level()
+}
+
+func (s PiecewiseStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	degree, chain := s.plan(coefficients_mult, interval, degree, budget)
+	return s.depth(coefficients_mult, interval, degree, chain)
+}
+
+// depth returns the levels consumed with pieces of the given degree and steps
+// by the given index of SignChains, negative for a single polynomial of that
+// degree.
+func (s PiecewiseStrategy) depth(coefficients_mult []float64, interval []float64, degree, chain int) int {
+	pieces := PolynomialStrategy{}.Depth(coefficients_mult, interval, degree, math.MaxInt)
+	if chain < 0 {
+		return pieces
+	}
+	return max(pieces, stepDepth(coefficients_mult, interval, SignChains[chain])) + 1
+}
+
+// stepDepth returns the levels consumed by evaluateStep on an inner product of
+// those weights.
+func stepDepth(coefficients_mult []float64, interval []float64, chain [2]int) int {
+	compositions := chain[0] + chain[1]
+	return PolynomialStrategy{}.Depth(coefficients_mult, interval, 7, math.MaxInt) + 3*(compositions-1)
+}
+
+// inside returns the breakpoints strictly inside the interval.
+func (s PiecewiseStrategy) inside(interval []float64) (breakpoints []float64) {
+	for _, b := range s.Breakpoints {
+		if b > interval[0] && b < interval[1] {
+			breakpoints = append(breakpoints, b)
+		}
+	}
+	return breakpoints
+}
+
+// stepPolynomials returns the polynomials whose composition is the step at b
+// of the chain, from 0 below b to 1 above, the first on the interval and the
+// others on [-1, 1].
+func stepPolynomials(b float64, interval []float64, chain [2]int) (polys []func(float64) float64) {
+	for i := 0; i < chain[0]; i++ {
+		polys = append(polys, g3)
+	}
+	for i := 0; i < chain[1]; i++ {
+		polys = append(polys, f3)
+	}
+	last := polys[len(polys)-1]
+	polys[len(polys)-1] = func(t float64) float64 { return (last(t) + 1) / 2 }
+	first := polys[0]
+	r := math.Max(b-interval[0], interval[1]-b)
+	polys[0] = func(x float64) float64 { return first((x - b) / r) }
+	return polys
+}
+
+// evaluateStep returns the step at b of the chain on the inner product of the
+// node, for values in the interval.
+func evaluateStep(n Node, b float64, interval []float64, chain [2]int, budget int, ev *Evaluation) (output *rlwe.Ciphertext) {
+	polys := stepPolynomials(b, interval, chain)
+	first := n
+	first.Activation = polys[0]
+	first.Approximation = GetChebyshevPoly
+	output, _ = PolynomialStrategy{}.Evaluate(first, interval, 7, budget, ev)
+	for _, f := range polys[1:] {
+		next := Node{
+			Coefficients_mult: []float64{1},
+			Activation:        f,
+			Input:             []*rlwe.Ciphertext{output},
+			Approximation:     GetChebyshevPoly,
+		}
+		output, _ = PolynomialStrategy{}.Evaluate(next, []float64{-1, 1}, 7, budget, ev)
+	}
+	return output
+}
+
+// g3 is the composite sign polynomial of Cheon, Kim and Kim of largest slope
+// at 0.
+func g3(t float64) float64 {
+	t2 := t * t
+	return t * (4589 - t2*(16577-t2*(25614-t2*12860))) / 1024
+}
+
+// f3 is the composite sign polynomial of Cheon, Kim and Kim flattest at -1
+// and 1.
+func f3(t float64) float64 {
+	t2 := t * t
+	return t * (35 - t2*(35-t2*(21-t2*5))) / 16
+}
+
+// pieces returns the polynomials of the given degree interpolating the
+// activation on each segment between the breakpoints inside the interval.
+func (s PiecewiseStrategy) pieces(interval []float64, degree int) (pieces []func(float64) float64) {
+	ends := append(append([]float64{interval[0]}, s.inside(interval)...), interval[1])
+	for j := 0; j+1 < len(ends); j++ {
+		pieces = append(pieces, interpolant(ends[j], ends[j+1], degree, s.Activation))
+	}
+	return pieces
+}
+
+// plan returns the degree of the pieces and the index in SignChains of the
+// steps to use, by running them on a grid of the interval.
+func (s PiecewiseStrategy) plan(coefficients_mult []float64, interval []float64, degree int, budget int) (int, int) {
+
+	points := grid(interval[0], interval[1], 2048)
+	largest := 0.0
+	for _, x := range points {
+		largest = math.Max(largest, math.Abs(s.Activation(x)))
+	}
+	breakpoints := s.inside(interval)
+
+	var plans [][2]int
+	var depths []int
+	var errs []float64
+	add := func(d, chain int, y func(x float64) float64) {
+		e := 0.0
+		for _, x := range points {
+			e = math.Max(e, math.Abs(y(x)-s.Activation(x)))
+		}
+		if math.IsNaN(e) {
+			e = math.Inf(1)
+		}
+		plans = append(plans, [2]int{d, chain})
+		depths = append(depths, s.depth(coefficients_mult, interval, d, chain))
+		errs = append(errs, e)
+	}
+
+	add(degree, -1, interpolant(interval[0], interval[1], degree, s.Activation))
+	for _, d := range degrees(degree) {
+		pieces := s.pieces(interval, d)
+		for chain := range SignChains {
+			steps := make([]func(float64) float64, len(breakpoints))
+			for j, b := range breakpoints {
+				polys := stepPolynomials(b, interval, SignChains[chain])
+				steps[j] = func(x float64) float64 {
+					for _, p := range polys {
+						x = p(x)
+					}
+					return x
+				}
+			}
+			add(d, chain, func(x float64) float64 {
+				y, previous := 0.0, 1.0
+				for j, g := range pieces {
+					next := 0.0
+					if j < len(steps) {
+						next = steps[j](x)
+					}
+					y += (previous - next) * g(x)
+					previous = next
+				}
+				return y
+			})
+		}
+	}
+
+	best := choose(depths, errs, PolynomialNoise*largest, budget)
+	if best < 0 {
+		return degree, -1
+	}
+	return plans[best][0], plans[best][1]
+}
@@ -0,0 +1,82 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+// TestPiecewiseStrategy evaluates abs and a linear spline of two segments at
+// points near their breakpoint and away from it, up to the ends of the
+// interval. The output must be that of the plaintext simulation of the plan,
+// and the blend of the two pieces must stay between them, so that the error
+// vanishes at the breakpoint, where the pieces meet, as well as at the ends,
+// where the step has settled.
+func TestPiecewiseStrategy(t *testing.T) {
+
+	k := newTestKeys(t)
+	spline := func(x float64) float64 {
+		if x < 1 {
+			return 0.5 * x
+		}
+		return 0.5 + 2*(x-1)
+	}
+	for _, tc := range []struct {
+		name       string
+		f          func(float64) float64
+		breakpoint float64
+		interval   []float64
+	}{
+		{"abs", math.Abs, 0, []float64{-2, 2}},
+		{"spline", spline, 1, []float64{-1, 3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var points []float64
+			for _, d := range []float64{1e-3, 1e-2, 0.05, 0.1, 0.3, 0.5, 0.9} {
+				points = append(points, tc.breakpoint-d, tc.breakpoint+d)
+			}
+			points = append(points, tc.interval...)
+
+			// The node degree of 15 leaves a single polynomial behind the
+			// pieces within the 10 levels of the parameters.
+			s := PiecewiseStrategy{Breakpoints: []float64{tc.breakpoint}, Activation: tc.f}
+			weights := []float64{1}
+			degree, chain := s.plan(weights, tc.interval, 15, k.params.MaxLevel())
+			if chain < 0 {
+				t.Fatal("a single polynomial")
+			}
+			pieces := s.pieces(tc.interval, degree)
+			polys := stepPolynomials(tc.breakpoint, tc.interval, SignChains[chain])
+
+			n := Node{
+				Coefficients_mult: weights,
+				Activation:        tc.f,
+				Input:             k.encrypt(t, [][]float64{points}),
+				Strategy:          s,
+			}
+			output, depth := n.evaluate(tc.interval, 15, k.params.MaxLevel(), NewEvaluation(k.eval, k.params))
+			if plan := s.Depth(weights, tc.interval, 15, k.params.MaxLevel()); depth != plan {
+				t.Errorf("depth %d, planned %d", depth, plan)
+			}
+			got := k.decrypt(t, output, len(points))
+			for i, x := range points {
+				step := x
+				for _, p := range polys {
+					step = p(step)
+				}
+				simulated := (1-step)*pieces[0](x) + step*pieces[1](x)
+				if e := math.Abs(got[i] - simulated); !(e < 1e-6) {
+					t.Errorf("%g: %g, simulated %g", x, got[i], simulated)
+				}
+				gap := math.Abs(pieces[0](x) - pieces[1](x))
+				if e := math.Abs(got[i] - tc.f(x)); !(e <= 1.01*gap+1e-6) {
+					t.Errorf("%g: error %.3e beyond the gap %.3e between the pieces", x, e, gap)
+				}
+			}
+			for i := len(points) - 2; i < len(points); i++ {
+				if e := math.Abs(got[i] - tc.f(points[i])); !(e < 1e-6) {
+					t.Errorf("end %g: error %.3e", points[i], e)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Step is one preprocessing operation on a named column. Kind is one of
+// "impute", "standardize", "minmax" or "onehot"; only the fields of that kind
+// are used.
+type Step struct {
+	Kind   string
+	Column string
+
+	Value float64 // impute: replacement for missing (NaN) values
+
+	Mean float64 // standardize: (x - Mean) / Std
+	Std  float64
+
+	Min float64 // minmax: (x - Min) / (Max - Min)
+	Max float64
+
+	Categories []float64 // onehot: one indicator column per category
+}
+
+// Pipeline is the preprocessing a model expects, applied in order on the
+// client before encryption.
+type Pipeline []Step
+
+// Affine returns the map x -> a*x + b of a standardize or minmax step.
+func (st Step) Affine() (a, b float64, ok bool) {
+	switch st.Kind {
+	case "standardize":
+		return 1 / st.Std, -st.Mean / st.Std, true
+	case "minmax":
+		return 1 / (st.Max - st.Min), -st.Min / (st.Max - st.Min), true
+	}
+	return 0, 0, false
+}
+
+// OneHotColumn is the name of the indicator column of category in column.
+func OneHotColumn(column string, category float64) string {
+	return column + "=" + strconv.FormatFloat(category, 'g', -1, 64)
+}
+
+// Outputs returns the columns produced by the step in place of Column.
+func (st Step) Outputs() []string {
+	if st.Kind != "onehot" {
+		return []string{st.Column}
+	}
+	names := make([]string, len(st.Categories))
+	for i, c := range st.Categories {
+		names[i] = OneHotColumn(st.Column, c)
+	}
+	return names
+}
+
+// Apply runs the pipeline on row-major data whose columns are named by
+// columns, and returns the resulting columns and rows. The input is not
+// modified.
+func (p Pipeline) Apply(columns []string, rows [][]float64) ([]string, [][]float64, error) {
+
+	columns = append([]string(nil), columns...)
+	out := make([][]float64, len(rows))
+	for i := range rows {
+		out[i] = append([]float64(nil), rows[i]...)
+	}
+
+	for _, st := range p {
+		j := -1
+		for k, name := range columns {
+			if name == st.Column {
+				j = k
+			}
+		}
+		if j < 0 {
+			return nil, nil, fmt.Errorf("preprocessing: no column %q", st.Column)
+		}
+
+		switch st.Kind {
+		case "impute":
+			for i := range out {
+				if math.IsNaN(out[i][j]) {
+					out[i][j] = st.Value
+				}
+			}
+		case "standardize", "minmax":
+			a, b, _ := st.Affine()
+			for i := range out {
+				out[i][j] = a*out[i][j] + b
+			}
+		case "onehot":
+			names := st.Outputs()
+			columns = append(columns[:j], append(names, columns[j+1:]...)...)
+			for i := range out {
+				indicator := make([]float64, len(st.Categories))
+				for k, c := range st.Categories {
+					if out[i][j] == c {
+						indicator[k] = 1
+					}
+				}
+				out[i] = append(out[i][:j], append(indicator, out[i][j+1:]...)...)
+			}
+		default:
+			return nil, nil, fmt.Errorf("preprocessing: unknown step %q", st.Kind)
+		}
+	}
+
+	return columns, out, nil
+}
+
+// Inputs returns the raw columns needed to produce the given columns.
+func (p Pipeline) Inputs(columns []string) []string {
+
+	source := make(map[string]string)
+	for _, st := range p {
+		if st.Kind == "onehot" {
+			for _, name := range st.Outputs() {
+				source[name] = st.Column
+			}
+		}
+	}
+
+	var inputs []string
+	seen := make(map[string]bool)
+	for _, name := range columns {
+		if raw, ok := source[name]; ok {
+			name = raw
+		}
+		if !seen[name] {
+			seen[name] = true
+			inputs = append(inputs, name)
+		}
+	}
+	return inputs
+}
@@ -0,0 +1,181 @@
+package src
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// RootStrategy evaluates c*sqrt(a*x + b) + d, or c/sqrt(a*x + b) + d if
+// Inverse, on a positive range of v = a*x + b by Newton iterations for the
+// inverse square root, y <- y (3 - v y^2)/2, from a polynomial initial guess
+// on the range. The square root is then v*y, with c folded in the weights of
+// v. Each iteration squares the relative error and consumes three levels,
+// against one per doubling of the degree of a single polynomial, which has to
+// follow the steep slope of both functions near 0. The initial degree and the
+// number of iterations are chosen on a plaintext simulation, with a single
+// polynomial of the node degree as one more candidate.
+type RootStrategy struct {
+	A, B, C, D float64
+	Inverse    bool
+}
+
+// NewRootStrategy returns the RootStrategy of a node of activation sqrt or
+// invsqrt whose interval maps to positive inputs of the activation.
+func NewRootStrategy(ns NodeSpec) (Strategy, error) {
+	if ns.Activation != "sqrt" && ns.Activation != "invsqrt" {
+		return nil, fmt.Errorf("root strategy on activation %q", ns.Activation)
+	}
+	a, b, c, d := ns.Maps()
+	s := RootStrategy{A: a, B: b, C: c, D: d, Inverse: ns.Activation == "invsqrt"}
+	if lo, _ := s.inputs(ns.Interval); lo <= 0 {
+		return nil, fmt.Errorf("root strategy on %v, which reaches %g", ns.Interval, lo)
+	}
+	return s, nil
+}
+
+func (s RootStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	var err error
+	level := n.Input[0].Level()
+	degree, iterations := s.plan(n.Coefficients_mult, interval, degree, budget)
+	if iterations == 0 {
+		return PolynomialStrategy{}.Evaluate(n, interval, degree, budget, ev)
+	}
+
+	guess := n
+	guess.Activation = func(x float64) float64 { return 1 / math.Sqrt(s.A*x+s.B) }
+	guess.Approximation = GetChebyshevPoly
+	y, _ := PolynomialStrategy{}.Evaluate(guess, interval, degree, budget, ev)
+
+	// v/2 and, for the square root, c*v.
+	scaled := func(k float64) *rlwe.Ciphertext {
+		coefficients_mult := make([]float64, len(n.Coefficients_mult))
+		for i, w := range n.Coefficients_mult {
+			coefficients_mult[i] = k * s.A * w
+		}
+		return Innerproduct(coefficients_mult, k*(s.A*n.Coefficient_add+s.B), n.Input, ev.Eval)
+	}
+	half := scaled(0.5)
+
+	mul := func(x, y *rlwe.Ciphertext) *rlwe.Ciphertext {
+		z, err := ev.Eval.MulRelinNew(x, y)
+		if err != nil {
+			panic(err)
+		}
+		if err = ev.Eval.Rescale(z, z); err != nil {
+			panic(err)
+		}
+		return z
+	}
+	for i := 0; i < iterations; i++ {
+		u := mul(half, mul(y, y))
+		if err = ev.Eval.Mul(u, -1, u); err != nil {
+			panic(err)
+		}
+		if err = ev.Eval.Add(u, 1.5, u); err != nil {
+			panic(err)
+		}
+		y = mul(y, u)
+	}
+
+	output = y
+	switch {
+	case !s.Inverse:
+		output = mul(scaled(s.C), y)
+	case s.C != 1:
+		if err = ev.Eval.Mul(output, s.C, output); err != nil {
+			panic(err)
+		}
+		if s.C != math.Trunc(s.C) {
+			if err = ev.Eval.Rescale(output, output); err != nil {
+				panic(err)
+			}
+		}
+	}
+	if err = ev.Eval.Add(output, s.D, output); err != nil {
+		panic(err)
+	}
+	return output, level - output.Level()
+}
+
+func (s RootStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	degree, iterations := s.plan(coefficients_mult, interval, degree, budget)
+	return s.depth(coefficients_mult, interval, degree, iterations)
+}
+
+// depth returns the levels consumed with the given initial degree and
+// number of iterations, none meaning a single polynomial of that degree.
+func (s RootStrategy) depth(coefficients_mult []float64, interval []float64, degree, iterations int) int {
+	depth := PolynomialStrategy{}.Depth(coefficients_mult, interval, degree, math.MaxInt)
+	if iterations == 0 {
+		return depth
+	}
+	depth += 3 * iterations
+	if !s.Inverse || s.C != math.Trunc(s.C) {
+		depth++
+	}
+	return depth
+}
+
+// inputs returns the range of a*x + b on the interval.
+func (s RootStrategy) inputs(interval []float64) (lo, hi float64) {
+	lo, hi = s.A*interval[0]+s.B, s.A*interval[1]+s.B
+	return math.Min(lo, hi), math.Max(lo, hi)
+}
+
+// plan returns the initial degree and the number of iterations to use, by
+// running them on a grid of the range.
+func (s RootStrategy) plan(coefficients_mult []float64, interval []float64, degree int, budget int) (int, int) {
+
+	lo, hi := s.inputs(interval)
+	f := math.Sqrt
+	if s.Inverse {
+		f = func(v float64) float64 { return 1 / math.Sqrt(v) }
+	}
+	points := grid(lo, hi, 1024)
+	largest := 0.0
+	for _, v := range points {
+		largest = math.Max(largest, math.Abs(f(v)))
+	}
+
+	var plans [][2]int
+	var depths []int
+	var errs []float64
+	add := func(d, iterations int, y func(v float64) float64) {
+		e := 0.0
+		for _, v := range points {
+			e = math.Max(e, math.Abs(y(v)-f(v)))
+		}
+		if math.IsNaN(e) {
+			e = math.Inf(1)
+		}
+		plans = append(plans, [2]int{d, iterations})
+		depths = append(depths, s.depth(coefficients_mult, interval, d, iterations))
+		errs = append(errs, e)
+	}
+
+	add(degree, 0, interpolant(lo, hi, degree, f))
+	for _, d := range degrees(degree) {
+		guess := interpolant(lo, hi, d, func(v float64) float64 { return 1 / math.Sqrt(v) })
+		for iterations := 1; iterations <= 6; iterations++ {
+			add(d, iterations, func(v float64) float64 {
+				y := guess(v)
+				for i := 0; i < iterations; i++ {
+					y *= 1.5 - v/2*y*y
+				}
+				if !s.Inverse {
+					y *= v
+				}
+				return y
+			})
+		}
+	}
+
+	best := choose(depths, errs, PolynomialNoise*largest, budget)
+	if best < 0 {
+		return degree, 0
+	}
+	return plans[best][0], plans[best][1]
+}
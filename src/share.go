@@ -0,0 +1,46 @@
+package src
+
+import "math"
+
+// ShareInputs returns a copy of m in which the single-input nodes of the first
+// layer read their feature as is: the weight and constant of a node move into
+// the input map of its activation, and its interval becomes the range of the
+// feature. All such nodes on a feature then have the same inner product, and
+// Block.Forward computes their power basis once. A node keeps its own inner
+// product if the range of its feature maps outside of its interval, where its
+// activation was not meant to be approximated.
+//
+// Sharing is opt-in, cmd/optimize -share, and not part of Optimize: the
+// polynomials of the shared nodes are fit anew on the range of the feature,
+// so the outputs change by their approximation error, and inputs beyond the
+// declared ranges then leave the intervals.
+func (m Model) ShareInputs(ranges [][]float64) Model {
+
+	m = m.Clone()
+	if len(m.Layers) == 0 {
+		return m
+	}
+	for i := range m.Layers[0].Nodes {
+		ns := &m.Layers[0].Nodes[i]
+		if len(ns.Input) != 1 || ns.Coefficients_mult[0] == 0 {
+			continue
+		}
+		w, b := ns.Coefficients_mult[0], ns.Coefficient_add
+		lo, hi := ranges[ns.Input[0]][0], ranges[ns.Input[0]][1]
+		if math.Min(w*lo, w*hi)+b < ns.Interval[0] || math.Max(w*lo, w*hi)+b > ns.Interval[1] || lo >= hi {
+			continue
+		}
+
+		a, c, _, _ := ns.Maps()
+		ns.Activation_in = []float64{a * w, a*b + c}
+		ns.Coefficients_mult[0], ns.Coefficient_add = 1, 0
+		ns.Interval = []float64{lo, hi}
+		for k := range ns.Samples {
+			ns.Samples[k] = (ns.Samples[k] - b) / w
+		}
+		for k := range ns.Breakpoints {
+			ns.Breakpoints[k] = (ns.Breakpoints[k] - b) / w
+		}
+	}
+	return m
+}
@@ -0,0 +1,182 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat/bootstrapping"
+	"github.com/tuneinsight/lattigo/v5/utils/bignum"
+)
+
+// Strategy is a way of evaluating the activation of a node on ciphertexts.
+type Strategy interface {
+	// Evaluate returns the activation of n applied to its inner product, for
+	// inner products in interval, consuming at most budget levels, and the
+	// levels it consumed.
+	Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int)
+	// Depth returns the levels Evaluate consumes on a node with the given
+	// weights, interval and degree when budget levels are left.
+	Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int
+}
+
+// Strategies maps the strategy names used in model files to constructors,
+// which fail on the nodes they do not apply to; the empty name is the
+// polynomial strategy.
+var Strategies = map[string]func(ns NodeSpec) (Strategy, error){
+	"":           func(NodeSpec) (Strategy, error) { return PolynomialStrategy{}, nil },
+	"polynomial": func(NodeSpec) (Strategy, error) { return PolynomialStrategy{}, nil },
+	"exp":        NewExpStrategy,
+	"sqrt":       NewRootStrategy,
+	"invsqrt":    NewRootStrategy,
+	"log":        NewLogStrategy,
+	"inverse":    NewInverseStrategy,
+	"piecewise":  NewPiecewiseStrategy,
+}
+
+// PolynomialNoise is the error of a polynomial evaluation relative to a result
+// of 1, which the strategies weigh against their approximation error.
+var PolynomialNoise = math.Exp2(-30)
+
+// Evaluation holds what the strategies of the nodes of a block share: the
+// evaluators, and the Chebyshev power bases computed so far.
+type Evaluation struct {
+	Eval     *hefloat.Evaluator
+	Params   hefloat.Parameters
+	PolyEval *hefloat.PolynomialEvaluator
+	Boot     *bootstrapping.Evaluator // refreshes the clamps, if any
+	bases    []sharedBasis
+}
+
+// sharedBasis is a power basis with the inner product it was computed on.
+type sharedBasis struct {
+	coefficients_mult []float64
+	coefficient_add   float64
+	input             []*rlwe.Ciphertext
+	basis             he.PowerBasis
+}
+
+// NewEvaluation returns an Evaluation with no power basis yet.
+func NewEvaluation(eval *hefloat.Evaluator, params hefloat.Parameters) *Evaluation {
+	return &Evaluation{
+		Eval:     eval,
+		Params:   params,
+		PolyEval: hefloat.NewPolynomialEvaluator(params, eval),
+	}
+}
+
+// Basis returns the Chebyshev power basis of the inner product of input with
+// the given weights and constant, computing it on first use. Inner products
+// are the same if their weights and constants are equal and they read the
+// same ciphertexts, in the same order.
+func (ev *Evaluation) Basis(coefficients_mult []float64, coefficient_add float64, input []*rlwe.Ciphertext) he.PowerBasis {
+	for _, b := range ev.bases {
+		if b.coefficient_add == coefficient_add && slices.Equal(b.coefficients_mult, coefficients_mult) && slices.Equal(b.input, input) {
+			return b.basis
+		}
+	}
+	basis := hefloat.NewPowerBasis(Innerproduct(coefficients_mult, coefficient_add, input, ev.Eval), bignum.Chebyshev)
+	ev.bases = append(ev.bases, sharedBasis{slices.Clone(coefficients_mult), coefficient_add, input, basis})
+	return basis
+}
+
+// PolynomialStrategy evaluates a single polynomial approximating the
+// activation on the interval, computed by the Approximation of the node. The
+// change of basis of the polynomial is fused with the inner product, and
+// nodes with the same fused inner product share their power basis.
+type PolynomialStrategy struct{}
+
+func (PolynomialStrategy) Evaluate(n Node, interval []float64, degree int, budget int, ev *Evaluation) (output *rlwe.Ciphertext, depth int) {
+
+	var err error
+	poly, coefficients_mult, coefficient_add := n.Polynomial(interval, degree)
+	basis := ev.Basis(coefficients_mult, coefficient_add, n.Input)
+	if output, err = ev.PolyEval.EvaluateFromPowerBasis(basis, poly, ev.Params.DefaultScale()); err != nil {
+		panic(err)
+	}
+	return output, n.Input[0].Level() - output.Level()
+}
+
+// Depth is the depth of the polynomial, and one for the inner product fused
+// with the change of basis unless its weights are then all integers, as for
+// integer weights on [-1, 1].
+func (PolynomialStrategy) Depth(coefficients_mult []float64, interval []float64, degree int, budget int) int {
+	levels := bits.Len(uint(degree))
+	scalar := 2 / (interval[1] - interval[0])
+	for _, w := range coefficients_mult {
+		if scalar*w != math.Trunc(scalar*w) {
+			levels++
+			break
+		}
+	}
+	return levels
+}
+
+// NewStrategy returns the strategy the node names.
+func (ns NodeSpec) NewStrategy() Strategy {
+	strategy, ok := Strategies[ns.Strategy]
+	if !ok {
+		panic(fmt.Errorf("unknown strategy %q", ns.Strategy))
+	}
+	s, err := strategy(ns)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// choose returns the index of the candidate to use among those that fit in
+// budget: the shallowest whose error is at most tolerance, else the most
+// accurate, earlier candidates winning unless later ones improve on them by
+// more than rounding. It returns -1 if none fits.
+func choose(depths []int, errs []float64, tolerance float64, budget int) int {
+	best := -1
+	for i := range depths {
+		switch {
+		case depths[i] > budget:
+		case best < 0:
+			best = i
+		case errs[best] <= tolerance:
+			if errs[i] <= tolerance && depths[i] < depths[best] {
+				best = i
+			}
+		case errs[i] < errs[best]*(1-1e-6):
+			best = i
+		}
+	}
+	return best
+}
+
+// degrees returns the degrees of the form 2^j - 1 below degree, which use their
+// levels fully, and degree itself.
+func degrees(degree int) (ds []int) {
+	for d := 1; d < degree; d = 2*d + 1 {
+		ds = append(ds, d)
+	}
+	return append(ds, degree)
+}
+
+// grid returns num points of [lo, hi], denser near the ends like the
+// Chebyshev nodes.
+func grid(lo, hi float64, num int) []float64 {
+	points := make([]float64, num)
+	for j := range points {
+		points[j] = lo + (1-math.Cos(math.Pi*float64(j)/float64(num-1)))*(hi-lo)/2
+	}
+	return points
+}
+
+// interpolant returns the Chebyshev interpolant of f on [lo, hi] as a plaintext
+// function.
+func interpolant(lo, hi float64, degree int, f func(float64) float64) func(float64) float64 {
+	poly := GetChebyshevPoly(lo, hi, degree, f)
+	coeffs := make([]float64, len(poly.Coeffs))
+	for i, c := range poly.Coeffs {
+		coeffs[i], _ = c.Real().Float64()
+	}
+	return func(x float64) float64 { return chebyshev(coeffs, (2*x-lo-hi)/(hi-lo)) }
+}
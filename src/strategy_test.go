@@ -0,0 +1,79 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+// evaluateNode evaluates the node of ns with its strategy on the encrypted
+// features, with every level of a fresh ciphertext as budget, and returns the
+// first slots of its output and the depth the strategy reported, which it
+// checks against that of the plan.
+func (k testKeys) evaluateNode(t *testing.T, ns NodeSpec, features [][]float64) (got []float64, depth int) {
+
+	bl, intervals, degrees := Layer{Nodes: []NodeSpec{ns}}.Block(k.encrypt(t, features))
+	n := bl.Nodes[0]
+	ev := NewEvaluation(k.eval, k.params)
+	ev.Boot = k.eval_boot
+	budget := n.Input[0].Level()
+	output, depth := n.evaluate(intervals[0], degrees[0], budget, ev)
+	if !n.Clamp {
+		if plan := n.strategy().Depth(n.Coefficients_mult, intervals[0], degrees[0], budget); depth != plan {
+			t.Errorf("depth %d, planned %d", depth, plan)
+		}
+	}
+	return k.decrypt(t, output, len(features[0])), depth
+}
+
+// TestSepsisStrategies evaluates the sqrt and log nodes of the sepsis model
+// with their strategies and with the Chebyshev interpolant of the node
+// degree, the baseline, on points of their intervals including both ends. A
+// strategy may only spend more levels than the baseline for an error ten
+// times lower, and is otherwise as precise up to the noise.
+func TestSepsisStrategies(t *testing.T) {
+
+	k := newTestKeys(t)
+	for _, tc := range []struct {
+		name     string
+		index    int // in the first layer
+		strategy string
+	}{
+		{"sqrt(x+0.37)", 10, "sqrt"},
+		{"log(3.4x+3.95)", 13, "log"},
+		{"log(4.25-1.38x)", 15, "log"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ns := SepsisModel().Layers[0].Nodes[tc.index]
+			w, b := ns.Coefficients_mult[0], ns.Coefficient_add
+			points := append(grid(ns.Interval[0], ns.Interval[1], 64), ns.Interval...)
+			for i := range points {
+				points[i] = (points[i] - b) / w
+			}
+			features := make([][]float64, ns.Input[0]+1)
+			for j := range features {
+				features[j] = points
+			}
+			f := ns.Function()
+			worst := func(got []float64) (e float64) {
+				for i, x := range points {
+					e = math.Max(e, math.Abs(got[i]-f(w*x+b)))
+				}
+				return e
+			}
+
+			baseline, baselineDepth := k.evaluateNode(t, ns, features)
+			ns.Strategy = tc.strategy
+			got, depth := k.evaluateNode(t, ns, features)
+			e, baselineErr := worst(got), worst(baseline)
+			t.Logf("error %.3e at depth %d, baseline %.3e at depth %d", e, depth, baselineErr, baselineDepth)
+			switch {
+			case depth > k.params.MaxLevel():
+				t.Errorf("depth %d beyond %d levels", depth, k.params.MaxLevel())
+			case depth > baselineDepth && !(e < baselineErr/10):
+				t.Errorf("error %.3e at depth %d for a baseline of %.3e at depth %d", e, depth, baselineErr, baselineDepth)
+			case !(e < baselineErr+1e-8):
+				t.Errorf("error %.3e above the baseline %.3e", e, baselineErr)
+			}
+		})
+	}
+}
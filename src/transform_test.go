@@ -0,0 +1,209 @@
+package src
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/JohnJimAir/asimpnetwork/dataset"
+)
+
+// builtin is a model with the rows to compare its transforms on.
+type builtin struct {
+	m    Model
+	rows [][]float64
+}
+
+// builtins returns the built-in models with their test sets.
+func builtins(t *testing.T) (models []builtin) {
+	for _, name := range []string{"breast-cancer", "sepsis"} {
+		m, err := LoadModel(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := dataset.Load("../data/test_data_"+name+".csv", dataset.Schema{Features: m.Features})
+		if err != nil {
+			t.Fatal(err)
+		}
+		models = append(models, builtin{m, data.Rows})
+	}
+	return models
+}
+
+// handBuilt returns a model of two features and two layers, the tanh and sin
+// of the first layer read by the given nodes of the second, on rows of
+// [-0.9, 0.9]^2.
+func handBuilt(nodes ...NodeSpec) builtin {
+	m := Model{
+		Name:     "hand-built",
+		Features: []string{"a", "b"},
+		Ranges:   [][]float64{{-1, 1}, {-1, 1}},
+		Layers: []Layer{
+			{Nodes: []NodeSpec{
+				{Input: []int{0}, Coefficients_mult: []float64{1}, Activation: "tanh", Interval: []float64{-1, 1}, Degree: 15},
+				{Input: []int{1}, Coefficients_mult: []float64{1}, Activation: "sin", Interval: []float64{-1, 1}, Degree: 15},
+			}},
+			{Nodes: nodes},
+		},
+	}
+	var rows [][]float64
+	for _, a := range grid(-0.9, 0.9, 7) {
+		for _, b := range grid(-0.9, 0.9, 7) {
+			rows = append(rows, []float64{a, b})
+		}
+	}
+	return builtin{m, rows}
+}
+
+// TestTransforms runs the transforms that keep the function of a model, on
+// the built-in models and on a hand-built one where the result is known: the
+// outputs must stay those of the model on its rows, and each transform reach
+// its own goal.
+func TestTransforms(t *testing.T) {
+
+	for _, tc := range []struct {
+		name      string
+		apply     func(m Model, rows [][]float64) Model
+		tolerance float64
+		check     func(t *testing.T, m, o Model, rows [][]float64)
+		hand      builtin                     // hand-built model, if any
+		want      func(t *testing.T, o Model) // on the transform of hand
+	}{
+		{
+			name:      "Optimize",
+			apply:     func(m Model, _ [][]float64) Model { return m.Optimize() },
+			tolerance: 1e-12,
+			check: func(t *testing.T, m, o Model, _ [][]float64) {
+				before, after := m.Cost(math.MaxInt), o.Cost(math.MaxInt)
+				for _, c := range []struct {
+					name          string
+					before, after int
+				}{
+					{"layers", before.Layers, after.Layers},
+					{"inputs", before.Inputs, after.Inputs},
+					{"nodes", before.Nodes, after.Nodes},
+					{"edges", before.Edges, after.Edges},
+					{"levels", before.Levels, after.Levels},
+					{"bootstraps", before.Bootstraps, after.Bootstraps},
+				} {
+					if c.after > c.before {
+						t.Errorf("%s from %d to %d", c.name, c.before, c.after)
+					}
+				}
+				if again := o.Optimize(); again.Cost(math.MaxInt) != after {
+					t.Errorf("a second pass changed the cost from %+v to %+v", after, again.Cost(math.MaxInt))
+				}
+			},
+			// The sin node is read with weight 0: it and its feature go.
+			hand: handBuilt(NodeSpec{Input: []int{0, 1}, Coefficients_mult: []float64{2, 0}, Coefficient_add: 0.5, Activation: "tanh", Interval: []float64{-4, 4}, Degree: 15}),
+			want: func(t *testing.T, o Model) {
+				if !reflect.DeepEqual(o.Features, []string{"a"}) {
+					t.Errorf("features %v", o.Features)
+				}
+				if nodes := o.Layers[0].Nodes; len(nodes) != 1 || nodes[0].Activation != "tanh" {
+					t.Errorf("first layer %+v", nodes)
+				}
+				if ns := o.Layers[1].Nodes[0]; !reflect.DeepEqual(ns.Input, []int{0}) || !reflect.DeepEqual(ns.Coefficients_mult, []float64{2}) {
+					t.Errorf("output node reads %v with weights %v", ns.Input, ns.Coefficients_mult)
+				}
+			},
+		},
+		{
+			name:      "FoldWeights",
+			apply:     func(m Model, _ [][]float64) Model { return m.Optimize().FoldWeights() },
+			tolerance: 1e-9,
+			check: func(t *testing.T, m, f Model, _ [][]float64) {
+				before, after := m.Optimize().Cost(math.MaxInt), f.Cost(math.MaxInt)
+				if after.Nodes != before.Nodes || after.Bootstraps != before.Bootstraps {
+					t.Errorf("nodes from %d to %d, bootstraps from %d to %d", before.Nodes, after.Nodes, before.Bootstraps, after.Bootstraps)
+				}
+				if after.Edges > before.Edges {
+					t.Errorf("edges from %d to %d", before.Edges, after.Edges)
+				}
+
+				// The nodes after the first layer read [-1, 1], and the edges
+				// out of a producer that carry its most common weight have
+				// weight 1.
+				for l := 1; l < len(f.Layers); l++ {
+					counts := make(map[int]map[float64]int)
+					for _, ns := range f.Layers[l].Nodes {
+						if ns.Interval[0] != -1 || ns.Interval[1] != 1 {
+							t.Fatalf("layer %d: interval %v", l, ns.Interval)
+						}
+						for k, i := range ns.Input {
+							if counts[i] == nil {
+								counts[i] = make(map[float64]int)
+							}
+							counts[i][ns.Coefficients_mult[k]]++
+						}
+					}
+					for i, c := range counts {
+						for w, n := range c {
+							if w != 0 && w != 1 && n > c[1] {
+								t.Errorf("layer %d: %d edges of weight %g out of node %d, %d of weight 1", l, n, w, i, c[1])
+							}
+						}
+					}
+				}
+			},
+			// On [-1, 1], the edges out of tanh weigh 0.25, 0.25 and 0.35,
+			// those out of sin 1 and 1.5: folding 0.25 and, on the tie, 1
+			// leaves the edges of 1.4 and 1.5, of the 4 edges of weight
+			// other than 1.
+			hand: handBuilt(
+				NodeSpec{Input: []int{0, 1}, Coefficients_mult: []float64{0.5, 2}, Activation: "tanh", Interval: []float64{-2, 2}, Degree: 15},
+				NodeSpec{Input: []int{0, 1}, Coefficients_mult: []float64{0.5, 3}, Activation: "tanh", Interval: []float64{-2, 2}, Degree: 15},
+				NodeSpec{Input: []int{0}, Coefficients_mult: []float64{0.7}, Activation: "tanh", Interval: []float64{-2, 2}, Degree: 15},
+			),
+			want: func(t *testing.T, f Model) {
+				if edges := f.Cost(math.MaxInt).Edges; edges != 2 {
+					t.Errorf("%d edges", edges)
+				}
+			},
+		},
+		{
+			name:      "Normalize",
+			apply:     func(m Model, rows [][]float64) Model { return m.Normalize(m.EmpiricalBounds(rows), 0.05) },
+			tolerance: 1e-9,
+			check: func(t *testing.T, _, n Model, rows [][]float64) {
+				// The calibration set itself stays within the new intervals,
+				// and the outputs of the inner layers within [-1, 1].
+				bounds, dead := n.EmpiricalBounds(rows), n.Dead()
+				for l, layer := range n.Layers {
+					for i, ns := range layer.Nodes {
+						if dead[l][i] {
+							continue
+						}
+						pre, post := bounds.Pre[l][i], bounds.Post[l][i]
+						if pre[0] < ns.Interval[0] || pre[1] > ns.Interval[1] {
+							t.Errorf("layer %d node %d: inputs %v outside %v", l, i, pre, ns.Interval)
+						}
+						if l < len(n.Layers)-1 && (post[0] < -1 || post[1] > 1) {
+							t.Errorf("layer %d node %d: outputs %v outside [-1, 1]", l, i, post)
+						}
+					}
+				}
+			},
+		},
+	} {
+		models := builtins(t)
+		if tc.want != nil {
+			models = append(models, tc.hand)
+		}
+		for _, b := range models {
+			t.Run(tc.name+"/"+b.m.Name, func(t *testing.T) {
+				o := tc.apply(b.m, b.rows)
+				if err := o.Check(); err != nil {
+					t.Fatal(err)
+				}
+				if worst := b.m.Equivalent(o, b.rows); !(worst < tc.tolerance) {
+					t.Errorf("outputs changed by %g", worst)
+				}
+				tc.check(t, b.m, o, b.rows)
+				if b.m.Name == tc.hand.m.Name {
+					tc.want(t, o)
+				}
+			})
+		}
+	}
+}